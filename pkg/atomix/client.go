@@ -25,8 +25,11 @@ import (
 	"github.com/atomix/atomix-go-client/pkg/atomix/list"
 	"github.com/atomix/atomix-go-client/pkg/atomix/lock"
 	_map "github.com/atomix/atomix-go-client/pkg/atomix/map"
+	"github.com/atomix/atomix-go-client/pkg/atomix/multimap"
 	"github.com/atomix/atomix-go-client/pkg/atomix/primitive"
+	"github.com/atomix/atomix-go-client/pkg/atomix/queue"
 	"github.com/atomix/atomix-go-client/pkg/atomix/set"
+	"github.com/atomix/atomix-go-client/pkg/atomix/treemap"
 	"github.com/atomix/atomix-go-client/pkg/atomix/value"
 	"github.com/atomix/atomix-go-framework/pkg/atomix/errors"
 	"github.com/atomix/atomix-go-framework/pkg/atomix/util/retry"
@@ -68,11 +71,27 @@ func GetMap(ctx context.Context, name string, opts ...primitive.Option) (_map.Ma
 	return getClient().GetMap(ctx, name, opts...)
 }
 
+// GetMultimap gets the Multimap instance of the given name
+func GetMultimap(ctx context.Context, name string, opts ...primitive.Option) (multimap.Multimap, error) {
+	return getClient().GetMultimap(ctx, name, opts...)
+}
+
+// GetQueue gets the Queue instance of the given name
+func GetQueue(ctx context.Context, name string, opts ...primitive.Option) (queue.Queue, error) {
+	return getClient().GetQueue(ctx, name, opts...)
+}
+
 // GetSet gets the Set instance of the given name
 func GetSet(ctx context.Context, name string, opts ...primitive.Option) (set.Set, error) {
 	return getClient().GetSet(ctx, name, opts...)
 }
 
+// GetTreeMap gets the TreeMap instance of the given name
+// TreeMap is a local stand-in for a key-ordered map intended for tests. See treemap.TreeMap.
+func GetTreeMap(ctx context.Context, name string, opts ...primitive.Option) (treemap.TreeMap, error) {
+	return getClient().GetTreeMap(ctx, name, opts...)
+}
+
 // GetValue gets the Value instance of the given name
 func GetValue(ctx context.Context, name string, opts ...primitive.Option) (value.Value, error) {
 	return getClient().GetValue(ctx, name, opts...)
@@ -102,7 +121,10 @@ type Client interface {
 	list.Client
 	lock.Client
 	_map.Client
+	multimap.Client
+	queue.Client
 	set.Client
+	treemap.Client
 	value.Client
 	io.Closer
 }
@@ -223,6 +245,22 @@ func (c *atomixClient) GetMap(ctx context.Context, name string, opts ...primitiv
 	return _map.New(ctx, name, conn, getPrimitiveOpts(c.options, opts...)...)
 }
 
+func (c *atomixClient) GetMultimap(ctx context.Context, name string, opts ...primitive.Option) (multimap.Multimap, error) {
+	conn, err := c.connect(ctx, newPrimitiveID(multimap.Type, multimap.MapName(name)))
+	if err != nil {
+		return nil, err
+	}
+	return multimap.New(ctx, name, conn, getPrimitiveOpts(c.options, opts...)...)
+}
+
+func (c *atomixClient) GetQueue(ctx context.Context, name string, opts ...primitive.Option) (queue.Queue, error) {
+	conn, err := c.connect(ctx, newPrimitiveID(queue.Type, queue.MapName(name)))
+	if err != nil {
+		return nil, err
+	}
+	return queue.New(ctx, name, conn, getPrimitiveOpts(c.options, opts...)...)
+}
+
 func (c *atomixClient) GetSet(ctx context.Context, name string, opts ...primitive.Option) (set.Set, error) {
 	conn, err := c.connect(ctx, newPrimitiveID(set.Type, name))
 	if err != nil {
@@ -231,6 +269,14 @@ func (c *atomixClient) GetSet(ctx context.Context, name string, opts ...primitiv
 	return set.New(ctx, name, conn, getPrimitiveOpts(c.options, opts...)...)
 }
 
+func (c *atomixClient) GetTreeMap(ctx context.Context, name string, opts ...primitive.Option) (treemap.TreeMap, error) {
+	conn, err := c.connect(ctx, newPrimitiveID(treemap.Type, treemap.MapName(name)))
+	if err != nil {
+		return nil, err
+	}
+	return treemap.New(ctx, name, conn, getPrimitiveOpts(c.options, opts...)...)
+}
+
 func (c *atomixClient) GetValue(ctx context.Context, name string, opts ...primitive.Option) (value.Value, error) {
 	conn, err := c.connect(ctx, newPrimitiveID(value.Type, name))
 	if err != nil {
@@ -62,7 +62,7 @@ type Election interface {
 	Evict(ctx context.Context, id string) (*Term, error)
 
 	// Watch watches the election for changes
-	Watch(ctx context.Context, ch chan<- Event) error
+	Watch(ctx context.Context, ch chan<- Event, opts ...WatchOption) error
 }
 
 // newTerm returns a new term from the response term
@@ -95,6 +95,11 @@ type EventType string
 const (
 	// EventChange indicates the election term changed
 	EventChange EventType = "change"
+
+	// EventResync indicates the watch stream was re-established and events may have been missed
+	// A resync event carries no term, and is followed by a change event if the term changed while the
+	// stream was down.
+	EventResync EventType = "resync"
 )
 
 // Event is an election event
@@ -207,24 +212,61 @@ func (e *election) Evict(ctx context.Context, id string) (*Term, error) {
 	return newTerm(&response.Term), nil
 }
 
-func (e *election) Watch(ctx context.Context, ch chan<- Event) error {
+func (e *election) Watch(ctx context.Context, ch chan<- Event, opts ...WatchOption) error {
 	request := &api.EventsRequest{
 		Headers: e.GetHeaders(),
 	}
+	for i := range opts {
+		opts[i].beforeWatch(request)
+	}
+
 	stream, err := e.client.Events(ctx, request)
 	if err != nil {
 		return errors.From(err)
 	}
 
+	// To resynchronize a watch after the stream is re-established, the revision of the term must be tracked
+	var tracker *termTracker
+	if isAutoReconnect(opts) {
+		term, err := e.GetTerm(ctx)
+		if err != nil {
+			return err
+		}
+		tracker = &termTracker{
+			revision: term.Revision,
+		}
+	}
+
+	buffer := primitive.NewEventBuffer(primitive.GetWatchBuffer(opts))
+	go buffer.Forward(ctx, ch)
+
+	send := func(event Event) bool {
+		if event.Type == EventResync {
+			return buffer.Push(ctx, "", event)
+		}
+		// Every event updates the same term, so events are coalesced under a single key
+		return buffer.Push(ctx, "term", event)
+	}
+	push := func(event Event) bool {
+		if tracker != nil && !tracker.update(event) {
+			return true
+		}
+		return send(event)
+	}
+
 	openCh := make(chan struct{})
 	go func() {
-		defer close(ch)
+		defer buffer.Close()
 		open := false
 		defer func() {
 			if !open {
 				close(openCh)
 			}
 		}()
+		cancel := func() {}
+		defer func() {
+			cancel()
+		}()
 		for {
 			response, err := stream.Recv()
 			if err == io.EOF ||
@@ -232,18 +274,32 @@ func (e *election) Watch(ctx context.Context, ch chan<- Event) error {
 				errors.IsTimeout(errors.From(err)) {
 				return
 			} else if err != nil {
-				log.Errorf("Watch failed: %v", err)
-				return
+				if tracker == nil {
+					log.Errorf("Watch failed: %v", err)
+					return
+				}
+				log.Warnf("Watch failed; reconnecting: %v", err)
+				cancel()
+				stream, cancel, err = e.resync(ctx, request, tracker, send)
+				if err != nil {
+					cancel = func() {}
+					return
+				}
 			} else {
 				if !open {
 					close(openCh)
 					open = true
 				}
+				for i := range opts {
+					opts[i].afterWatch(response)
+				}
 				switch response.Event.Type {
 				case api.Event_CHANGED:
-					ch <- Event{
+					if !push(Event{
 						Type: EventChange,
 						Term: *newTerm(&response.Event.Term),
+					}) {
+						return
 					}
 				}
 			}
@@ -277,3 +277,55 @@ func TestElectionOperations(t *testing.T) {
 
 	assert.NoError(t, test.Stop())
 }
+
+func TestElectionWatchResync(t *testing.T) {
+	logging.SetLevel(logging.DebugLevel)
+
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestElectionWatchResync",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn1, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	conn2, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	election, err := New(context.TODO(), "TestElectionWatchResync", conn1, primitive.WithSessionID("client-1"))
+	assert.NoError(t, err)
+
+	candidate, err := New(context.TODO(), "TestElectionWatchResync", conn2, primitive.WithSessionID("client-2"))
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := make(chan Event)
+	err = election.Watch(ctx, ch, WithAutoReconnect())
+	assert.NoError(t, err)
+
+	_, err = election.Enter(context.Background())
+	assert.NoError(t, err)
+	event := <-ch
+	assert.Equal(t, EventChange, event.Type)
+
+	// A broken stream is re-established and signaled with a resync event
+	test.BreakStreams()
+	event = <-ch
+	assert.Equal(t, EventResync, event.Type)
+
+	_, err = candidate.Enter(context.Background())
+	assert.NoError(t, err)
+	_, err = election.Leave(context.Background())
+	assert.NoError(t, err)
+	for event.Term.Leader != candidate.ID() {
+		event = <-ch
+		assert.Equal(t, EventChange, event.Type)
+	}
+
+	assert.NoError(t, test.Stop())
+}
@@ -52,8 +52,28 @@ type IndexedMap interface {
 	Append(ctx context.Context, key string, value []byte) (*Entry, error)
 
 	// Put appends the given key/value to the map
+	// If the key is already present, its value is updated in place and the entry keeps its index.
 	Put(ctx context.Context, key string, value []byte) (*Entry, error)
 
+	// Upsert updates the value of the given key in place, or appends the key if it's not present
+	// The entry keeps its index when it's updated. Options can be provided to condition the update on the
+	// current version of the entry.
+	Upsert(ctx context.Context, key string, value []byte, opts ...SetOption) (*Entry, error)
+
+	// InsertAfter inserts the given key/value immediately after the entry at the given index
+	// Indexes are assigned in increasing order, so an entry can only be inserted after the last entry in the
+	// map. A NotSupported error is returned for any other index.
+	// If another entry is appended concurrently, the new entry is removed and a Conflict error is returned. The
+	// new entry is visible, including to watchers, until it's removed. If the new entry can't be removed, the
+	// error that prevented its removal is returned, and the entry may still be in the map.
+	InsertAfter(ctx context.Context, index Index, key string, value []byte) (*Entry, error)
+
+	// InsertBefore inserts the given key/value immediately before the entry at the given index
+	// Indexes are assigned in increasing order, so an entry can never be inserted before an existing entry.
+	// InsertBefore is not supported: a NotSupported error is returned if the entry exists, or a NotFound error
+	// if it does not.
+	InsertBefore(ctx context.Context, index Index, key string, value []byte) (*Entry, error)
+
 	// Set sets the given index in the map
 	Set(ctx context.Context, index Index, key string, value []byte, opts ...SetOption) (*Entry, error)
 
@@ -93,12 +113,34 @@ type IndexedMap interface {
 	// RemoveIndex removes an index from the map
 	RemoveIndex(ctx context.Context, index Index, opts ...RemoveOption) (*Entry, error)
 
+	// RemoveBefore removes all entries with an index lower than the given index
+	// Returns the number of entries removed. Entries are removed one at a time in index order.
+	RemoveBefore(ctx context.Context, index Index) (int, error)
+
+	// Trim removes entries from the first index until the map holds no more than the given number of entries
+	// Returns the number of entries removed. Entries are removed one at a time in index order, and the size of the
+	// map is checked before each removal so concurrent trims don't remove more entries than necessary.
+	Trim(ctx context.Context, maxEntries int) (int, error)
+
 	// Len returns the number of entries in the map
 	Len(ctx context.Context) (int, error)
 
 	// Clear removes all entries from the map
 	Clear(ctx context.Context) error
 
+	// Iterate returns an iterator over the entries in the map
+	// The iterator must be closed once the caller is done with it.
+	Iterate(ctx context.Context) (EntryIterator, error)
+
+	// Range returns an iterator over the entries with indexes in the range [from, to)
+	// A from or to index of 0 leaves the range unbounded on that side. Options can be provided to change the
+	// inclusiveness of the bounds, to iterate in descending index order, and to limit the number of entries.
+	// Ranges are read from a single stream that stops at the end of the range. Descending ranges are read in
+	// ascending order when the first entry is requested and returned in reverse, holding no more than the limit
+	// of entries in memory if one is set.
+	// The iterator must be closed once the caller is done with it.
+	Range(ctx context.Context, from, to Index, opts ...RangeOption) (EntryIterator, error)
+
 	// Entries lists the entries in the map
 	// This is a non-blocking method. If the method returns without error, key/value paids will be pushed on to the
 	// given channel and the channel will be closed once all entries have been read from the map.
@@ -144,6 +186,14 @@ const (
 
 	// EventReplay indicates an entry was replayed
 	EventReplay EventType = "replay"
+
+	// EventSynced indicates all the entries in the map have been replayed
+	EventSynced EventType = "synced"
+
+	// EventResync indicates the watch stream was re-established and events may have been missed
+	// A resync event carries no entry, and is followed by an event for each key that changed while the
+	// stream was down.
+	EventResync EventType = "resync"
 )
 
 // Event is a map change event
@@ -153,6 +203,11 @@ type Event struct {
 
 	// Entry is the event entry
 	Entry Entry
+
+	// PrevEntry is the entry replaced or removed by the change
+	// The previous entry is only populated for watches opened with the WithPrevValues option, and is nil
+	// if the key did not exist before the change or its previous entry is not known.
+	PrevEntry *Entry
 }
 
 // New creates a new IndexedMap primitive
@@ -193,7 +248,25 @@ func newEntry(entry *api.Entry) *Entry {
 	}
 }
 
+// decodeEntry converts the given entry to an Entry, decrypting the value if necessary
+func (m *indexedMap) decodeEntry(entry *api.Entry) (*Entry, error) {
+	result := newEntry(entry)
+	if result == nil {
+		return nil, nil
+	}
+	value, err := m.Decrypt(result.Value)
+	if err != nil {
+		return nil, err
+	}
+	result.Value = value
+	return result, nil
+}
+
 func (m *indexedMap) Append(ctx context.Context, key string, value []byte) (*Entry, error) {
+	value, err := m.Encrypt(value)
+	if err != nil {
+		return nil, err
+	}
 	request := &api.PutRequest{
 		Headers: m.GetHeaders(),
 		Entry: api.Entry{
@@ -218,10 +291,18 @@ func (m *indexedMap) Append(ctx context.Context, key string, value []byte) (*Ent
 	if err != nil {
 		return nil, errors.From(err)
 	}
-	return newEntry(response.Entry), nil
+	return m.decodeEntry(response.Entry)
 }
 
 func (m *indexedMap) Put(ctx context.Context, key string, value []byte) (*Entry, error) {
+	return m.Upsert(ctx, key, value)
+}
+
+func (m *indexedMap) Upsert(ctx context.Context, key string, value []byte, opts ...SetOption) (*Entry, error) {
+	value, err := m.Encrypt(value)
+	if err != nil {
+		return nil, err
+	}
 	request := &api.PutRequest{
 		Headers: m.GetHeaders(),
 		Entry: api.Entry{
@@ -233,14 +314,62 @@ func (m *indexedMap) Put(ctx context.Context, key string, value []byte) (*Entry,
 			},
 		},
 	}
+	for i := range opts {
+		opts[i].beforePut(request)
+	}
 	response, err := m.client.Put(ctx, request)
 	if err != nil {
 		return nil, errors.From(err)
 	}
-	return newEntry(response.Entry), nil
+	for i := range opts {
+		opts[i].afterPut(response)
+	}
+	return m.decodeEntry(response.Entry)
+}
+
+func (m *indexedMap) InsertAfter(ctx context.Context, index Index, key string, value []byte) (*Entry, error) {
+	if _, err := m.GetIndex(ctx, index); err != nil {
+		return nil, err
+	}
+	last, err := m.LastIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if last != index {
+		return nil, errors.NewNotSupported("cannot insert after index %d: entries can only be inserted after the last index", index)
+	}
+
+	entry, err := m.Append(ctx, key, value)
+	if err != nil {
+		return nil, err
+	}
+
+	// If another entry was appended before the new entry, the new entry is removed to honor the requested position
+	prev, err := m.PrevIndex(ctx, entry.Index)
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, err
+	}
+	if prev != index {
+		if _, err := m.RemoveIndex(ctx, entry.Index, IfMatch(entry)); err != nil && !errors.IsNotFound(err) && !errors.IsConflict(err) {
+			return nil, errors.From(err)
+		}
+		return nil, errors.NewConflict("cannot insert after index %d: an entry was appended concurrently", index)
+	}
+	return entry, nil
+}
+
+func (m *indexedMap) InsertBefore(ctx context.Context, index Index, key string, value []byte) (*Entry, error) {
+	if _, err := m.GetIndex(ctx, index); err != nil {
+		return nil, err
+	}
+	return nil, errors.NewNotSupported("cannot insert before index %d: entries can only be inserted after the last index", index)
 }
 
 func (m *indexedMap) Set(ctx context.Context, index Index, key string, value []byte, opts ...SetOption) (*Entry, error) {
+	value, err := m.Encrypt(value)
+	if err != nil {
+		return nil, err
+	}
 	request := &api.PutRequest{
 		Headers: m.GetHeaders(),
 		Entry: api.Entry{
@@ -263,7 +392,7 @@ func (m *indexedMap) Set(ctx context.Context, index Index, key string, value []b
 	for i := range opts {
 		opts[i].afterPut(response)
 	}
-	return newEntry(response.Entry), nil
+	return m.decodeEntry(response.Entry)
 }
 
 func (m *indexedMap) Get(ctx context.Context, key string, opts ...GetOption) (*Entry, error) {
@@ -283,7 +412,7 @@ func (m *indexedMap) Get(ctx context.Context, key string, opts ...GetOption) (*E
 	for i := range opts {
 		opts[i].afterGet(response)
 	}
-	return newEntry(response.Entry), nil
+	return m.decodeEntry(response.Entry)
 }
 
 func (m *indexedMap) GetIndex(ctx context.Context, index Index, opts ...GetOption) (*Entry, error) {
@@ -303,7 +432,7 @@ func (m *indexedMap) GetIndex(ctx context.Context, index Index, opts ...GetOptio
 	for i := range opts {
 		opts[i].afterGet(response)
 	}
-	return newEntry(response.Entry), nil
+	return m.decodeEntry(response.Entry)
 }
 
 func (m *indexedMap) FirstIndex(ctx context.Context) (Index, error) {
@@ -360,7 +489,7 @@ func (m *indexedMap) FirstEntry(ctx context.Context) (*Entry, error) {
 	if err != nil {
 		return nil, errors.From(err)
 	}
-	return newEntry(response.Entry), nil
+	return m.decodeEntry(response.Entry)
 }
 
 func (m *indexedMap) LastEntry(ctx context.Context) (*Entry, error) {
@@ -371,7 +500,7 @@ func (m *indexedMap) LastEntry(ctx context.Context) (*Entry, error) {
 	if err != nil {
 		return nil, errors.From(err)
 	}
-	return newEntry(response.Entry), nil
+	return m.decodeEntry(response.Entry)
 }
 
 func (m *indexedMap) PrevEntry(ctx context.Context, index Index) (*Entry, error) {
@@ -383,7 +512,7 @@ func (m *indexedMap) PrevEntry(ctx context.Context, index Index) (*Entry, error)
 	if err != nil {
 		return nil, errors.From(err)
 	}
-	return newEntry(response.Entry), nil
+	return m.decodeEntry(response.Entry)
 }
 
 func (m *indexedMap) NextEntry(ctx context.Context, index Index) (*Entry, error) {
@@ -395,7 +524,7 @@ func (m *indexedMap) NextEntry(ctx context.Context, index Index) (*Entry, error)
 	if err != nil {
 		return nil, errors.From(err)
 	}
-	return newEntry(response.Entry), nil
+	return m.decodeEntry(response.Entry)
 }
 
 func (m *indexedMap) Remove(ctx context.Context, key string, opts ...RemoveOption) (*Entry, error) {
@@ -417,7 +546,7 @@ func (m *indexedMap) Remove(ctx context.Context, key string, opts ...RemoveOptio
 	for i := range opts {
 		opts[i].afterRemove(response)
 	}
-	return newEntry(response.Entry), nil
+	return m.decodeEntry(response.Entry)
 }
 
 func (m *indexedMap) RemoveIndex(ctx context.Context, index Index, opts ...RemoveOption) (*Entry, error) {
@@ -439,7 +568,7 @@ func (m *indexedMap) RemoveIndex(ctx context.Context, index Index, opts ...Remov
 	for i := range opts {
 		opts[i].afterRemove(response)
 	}
-	return newEntry(response.Entry), nil
+	return m.decodeEntry(response.Entry)
 }
 
 func (m *indexedMap) Len(ctx context.Context) (int, error) {
@@ -464,28 +593,35 @@ func (m *indexedMap) Clear(ctx context.Context) error {
 	return nil
 }
 
-func (m *indexedMap) Entries(ctx context.Context, ch chan<- Entry) error {
+func (m *indexedMap) Iterate(ctx context.Context) (EntryIterator, error) {
 	request := &api.EntriesRequest{
 		Headers: m.GetHeaders(),
 	}
+	ctx, cancel := context.WithCancel(ctx)
 	stream, err := m.client.Entries(ctx, request)
 	if err != nil {
-		return errors.From(err)
+		cancel()
+		return nil, errors.From(err)
 	}
-
-	go func() {
-		defer close(ch)
-		for {
-			response, err := stream.Recv()
-			if err == io.EOF {
-				return
-			} else if err != nil {
-				log.Errorf("Entries failed: %v", err)
-			} else {
-				ch <- *newEntry(&response.Entry)
+	return &entryIterator{
+		stream: stream,
+		cancel: cancel,
+		decode: func(response *api.EntriesResponse) (Entry, error) {
+			entry, err := m.decodeEntry(&response.Entry)
+			if err != nil {
+				return Entry{}, err
 			}
-		}
-	}()
+			return *entry, nil
+		},
+	}, nil
+}
+
+func (m *indexedMap) Entries(ctx context.Context, ch chan<- Entry) error {
+	iterator, err := m.Iterate(ctx)
+	if err != nil {
+		return err
+	}
+	go pushEntries(ctx, iterator, ch)
 	return nil
 }
 
@@ -497,20 +633,80 @@ func (m *indexedMap) Watch(ctx context.Context, ch chan<- Event, opts ...WatchOp
 		opts[i].beforeWatch(request)
 	}
 
+	// Replay is performed by the client so the end of the replay can be signaled to the consumer
+	replay := request.Replay
+	request.Replay = false
+
 	stream, err := m.client.Events(ctx, request)
 	if err != nil {
 		return errors.From(err)
 	}
 
+	// To resynchronize a watch after the stream is re-established, the revision of each key must be tracked
+	// Replayed entries are tracked to discard events for changes already reflected in the replay.
+	// To populate the previous entry of events, the entries in the map must be tracked
+	var tracker *revisionTracker
+	var view *entryView
+	if isPrevValues(opts) {
+		view = newEntryView()
+	}
+	if replay {
+		tracker = newRevisionTracker()
+	} else if isAutoReconnect(opts) || view != nil {
+		entries, err := m.readEntries(ctx, request)
+		if err != nil {
+			return err
+		}
+		if isAutoReconnect(opts) {
+			tracker = newRevisionTracker()
+		}
+		for _, entry := range entries {
+			if tracker != nil {
+				tracker.update(Event{Type: EventReplay, Entry: entry})
+			}
+			if view != nil {
+				view.update(&Event{Type: EventReplay, Entry: entry})
+			}
+		}
+	}
+
+	buffer := primitive.NewEventBuffer(primitive.GetWatchBuffer(opts))
+	go buffer.Forward(ctx, ch)
+
+	// Changes to entries below the start index are not delivered
+	start := getStartIndex(opts)
+	send := func(event Event) bool {
+		if event.Type == EventResync {
+			return buffer.Push(ctx, "", event)
+		}
+		if event.Entry.Index < start {
+			return true
+		}
+		if view != nil {
+			view.update(&event)
+		}
+		return buffer.Push(ctx, event.Entry.Key, event)
+	}
+	push := func(event Event) bool {
+		if tracker != nil && !tracker.update(event) {
+			return true
+		}
+		return send(event)
+	}
+
 	openCh := make(chan struct{})
 	go func() {
-		defer close(ch)
+		defer buffer.Close()
 		open := false
 		defer func() {
 			if !open {
 				close(openCh)
 			}
 		}()
+		cancel := func() {}
+		defer func() {
+			cancel()
+		}()
 		for {
 			response, err := stream.Recv()
 			if err == io.EOF ||
@@ -518,39 +714,45 @@ func (m *indexedMap) Watch(ctx context.Context, ch chan<- Event, opts ...WatchOp
 				errors.IsTimeout(errors.From(err)) {
 				return
 			} else if err != nil {
-				log.Errorf("Watch failed: %v", err)
-				return
+				if tracker == nil || !isAutoReconnect(opts) {
+					log.Errorf("Watch failed: %v", err)
+					return
+				}
+				log.Warnf("Watch failed; reconnecting: %v", err)
+				cancel()
+				stream, cancel, err = m.resync(ctx, request, tracker, send)
+				if err != nil {
+					cancel = func() {}
+					return
+				}
 			} else {
 				if !open {
 					close(openCh)
 					open = true
+					if replay {
+						if err := m.replay(ctx, request, start, tracker, send); err != nil {
+							if ctx.Err() == nil {
+								log.Errorf("Watch failed: %v", err)
+							}
+							return
+						}
+						if !buffer.Push(ctx, "", Event{Type: EventSynced}) {
+							return
+						}
+					}
 				}
 
 				for i := range opts {
 					opts[i].afterWatch(response)
 				}
 
-				switch response.Event.Type {
-				case api.Event_INSERT:
-					ch <- Event{
-						Type:  EventInsert,
-						Entry: *newEntry(&response.Event.Entry),
-					}
-				case api.Event_UPDATE:
-					ch <- Event{
-						Type:  EventUpdate,
-						Entry: *newEntry(&response.Event.Entry),
-					}
-				case api.Event_REMOVE:
-					ch <- Event{
-						Type:  EventRemove,
-						Entry: *newEntry(&response.Event.Entry),
-					}
-				case api.Event_REPLAY:
-					ch <- Event{
-						Type:  EventReplay,
-						Entry: *newEntry(&response.Event.Entry),
-					}
+				event, err := m.decodeEvent(&response.Event)
+				if err != nil {
+					log.Errorf("Failed to decode event: %v", err)
+					continue
+				}
+				if event != nil && !push(*event) {
+					return
 				}
 			}
 		}
@@ -563,3 +765,28 @@ func (m *indexedMap) Watch(ctx context.Context, ch chan<- Event, opts ...WatchOp
 		return ctx.Err()
 	}
 }
+
+// decodeEvent converts the given event to an Event, returning nil if the event is not a change event
+func (m *indexedMap) decodeEvent(event *api.Event) (*Event, error) {
+	var eventType EventType
+	switch event.Type {
+	case api.Event_INSERT:
+		eventType = EventInsert
+	case api.Event_UPDATE:
+		eventType = EventUpdate
+	case api.Event_REMOVE:
+		eventType = EventRemove
+	case api.Event_REPLAY:
+		eventType = EventReplay
+	default:
+		return nil, nil
+	}
+	entry, err := m.decodeEntry(&event.Entry)
+	if err != nil {
+		return nil, err
+	}
+	return &Event{
+		Type:  eventType,
+		Entry: *entry,
+	}, nil
+}
@@ -17,11 +17,18 @@ package indexedmap
 import (
 	"context"
 	primitiveapi "github.com/atomix/atomix-api/go/atomix/primitive"
+	api "github.com/atomix/atomix-api/go/atomix/primitive/indexedmap"
 	"github.com/atomix/atomix-go-client/pkg/atomix/util/test"
 	"github.com/atomix/atomix-go-framework/pkg/atomix/errors"
 	"github.com/atomix/atomix-go-framework/pkg/atomix/logging"
 	"github.com/atomix/atomix-go-framework/pkg/atomix/meta"
+	"google.golang.org/grpc"
+	"io"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -363,3 +370,666 @@ func TestIndexedMapStreams(t *testing.T) {
 
 	assert.NoError(t, test.Stop())
 }
+
+func TestIndexedMapIterator(t *testing.T) {
+	logging.SetLevel(logging.DebugLevel)
+
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestIndexedMapIterator",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	_map, err := New(context.TODO(), "TestIndexedMapIterator", conn)
+	assert.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		_, err = _map.Append(context.Background(), strconv.Itoa(i), []byte(strconv.Itoa(i)))
+		assert.NoError(t, err)
+	}
+
+	iterator, err := _map.Iterate(context.Background())
+	assert.NoError(t, err)
+	elements := make([]string, 0)
+	for {
+		entry, err := iterator.Next(context.Background())
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		elements = append(elements, entry.Key)
+	}
+	assert.Equal(t, []string{"0", "1", "2"}, elements)
+	_, err = iterator.Next(context.Background())
+	assert.Equal(t, io.EOF, err)
+	iterator.Close()
+
+	iterator, err = _map.Iterate(context.Background())
+	assert.NoError(t, err)
+	_, err = iterator.Next(context.Background())
+	assert.NoError(t, err)
+	iterator.Close()
+	_, err = iterator.Next(context.Background())
+	assert.True(t, errors.IsCanceled(err))
+
+	iterator, err = _map.Iterate(context.Background())
+	assert.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = iterator.Next(ctx)
+	assert.True(t, errors.IsCanceled(err))
+	_, err = iterator.Next(context.Background())
+	assert.True(t, errors.IsCanceled(err))
+	iterator.Close()
+
+	assert.NoError(t, test.Stop())
+}
+
+func TestIndexedMapWatchSynced(t *testing.T) {
+	logging.SetLevel(logging.DebugLevel)
+
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestIndexedMapWatchSynced",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	_map, err := New(context.TODO(), "TestIndexedMapWatchSynced", conn)
+	assert.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		_, err = _map.Append(context.Background(), strconv.Itoa(i), []byte(strconv.Itoa(i)))
+		assert.NoError(t, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := make(chan Event)
+	err = _map.Watch(ctx, ch, WithReplay())
+	assert.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		event := <-ch
+		assert.Equal(t, EventReplay, event.Type)
+		assert.Equal(t, strconv.Itoa(i), event.Entry.Key)
+	}
+	event := <-ch
+	assert.Equal(t, EventSynced, event.Type)
+
+	_, err = _map.Append(context.Background(), "3", []byte("3"))
+	assert.NoError(t, err)
+	event = <-ch
+	assert.Equal(t, EventInsert, event.Type)
+	assert.Equal(t, "3", event.Entry.Key)
+
+	assert.NoError(t, test.Stop())
+}
+
+func TestIndexedMapWatchStartIndex(t *testing.T) {
+	logging.SetLevel(logging.DebugLevel)
+
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestIndexedMapWatchStartIndex",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	_map, err := New(context.TODO(), "TestIndexedMapWatchStartIndex", conn)
+	assert.NoError(t, err)
+
+	indexes := make([]Index, 0)
+	for i := 0; i < 5; i++ {
+		entry, err := _map.Append(context.Background(), strconv.Itoa(i), []byte(strconv.Itoa(i)))
+		assert.NoError(t, err)
+		indexes = append(indexes, entry.Index)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := make(chan Event)
+	// The start index is inclusive, so a consumer that processed the entry at indexes[1] resumes from the next index
+	err = _map.Watch(ctx, ch, WithStartIndex(indexes[1]+1))
+	assert.NoError(t, err)
+
+	for i := 2; i < 5; i++ {
+		event := <-ch
+		assert.Equal(t, EventReplay, event.Type)
+		assert.Equal(t, indexes[i], event.Entry.Index)
+		assert.Equal(t, strconv.Itoa(i), event.Entry.Key)
+	}
+	event := <-ch
+	assert.Equal(t, EventSynced, event.Type)
+
+	// Changes to entries below the start index are not delivered
+	_, err = _map.Put(context.Background(), "0", []byte("updated"))
+	assert.NoError(t, err)
+	_, err = _map.Remove(context.Background(), "1")
+	assert.NoError(t, err)
+	_, err = _map.Append(context.Background(), "5", []byte("5"))
+	assert.NoError(t, err)
+	event = <-ch
+	assert.Equal(t, EventInsert, event.Type)
+	assert.Equal(t, "5", event.Entry.Key)
+
+	assert.NoError(t, test.Stop())
+}
+
+func TestIndexedMapWatchPrevValues(t *testing.T) {
+	logging.SetLevel(logging.DebugLevel)
+
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestIndexedMapWatchPrevValues",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	_map, err := New(context.TODO(), "TestIndexedMapWatchPrevValues", conn)
+	assert.NoError(t, err)
+
+	foo, err := _map.Append(context.Background(), "foo", []byte("foo"))
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := make(chan Event)
+	err = _map.Watch(ctx, ch, WithPrevValues())
+	assert.NoError(t, err)
+
+	_, err = _map.Set(context.Background(), foo.Index, "foo", []byte("bar"))
+	assert.NoError(t, err)
+	event := <-ch
+	assert.Equal(t, EventUpdate, event.Type)
+	assert.Equal(t, "bar", string(event.Entry.Value))
+	assert.NotNil(t, event.PrevEntry)
+	assert.Equal(t, "foo", string(event.PrevEntry.Value))
+
+	_, err = _map.Append(context.Background(), "bar", []byte("bar"))
+	assert.NoError(t, err)
+	event = <-ch
+	assert.Equal(t, EventInsert, event.Type)
+	assert.Nil(t, event.PrevEntry)
+
+	_, err = _map.Remove(context.Background(), "foo")
+	assert.NoError(t, err)
+	event = <-ch
+	assert.Equal(t, EventRemove, event.Type)
+	assert.NotNil(t, event.PrevEntry)
+	assert.Equal(t, "bar", string(event.PrevEntry.Value))
+
+	assert.NoError(t, test.Stop())
+}
+
+func TestIndexedMapRange(t *testing.T) {
+	logging.SetLevel(logging.DebugLevel)
+
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestIndexedMapRange",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	m, err := New(context.TODO(), "TestIndexedMapRange", conn)
+	assert.NoError(t, err)
+
+	for i := 1; i <= 10; i++ {
+		_, err = m.Append(context.TODO(), strconv.Itoa(i), []byte(strconv.Itoa(i)))
+		assert.NoError(t, err)
+	}
+	_, err = m.Remove(context.TODO(), "5")
+	assert.NoError(t, err)
+
+	rangeIndexes := func(from, to Index, opts ...RangeOption) []Index {
+		iterator, err := m.Range(context.TODO(), from, to, opts...)
+		assert.NoError(t, err)
+		defer iterator.Close()
+		indexes := make([]Index, 0)
+		for {
+			entry, err := iterator.Next(context.TODO())
+			if err == io.EOF {
+				return indexes
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, strconv.Itoa(int(entry.Index)), entry.Key)
+			indexes = append(indexes, entry.Index)
+		}
+	}
+
+	assert.Equal(t, []Index{1, 2, 3, 4, 6, 7, 8, 9, 10}, rangeIndexes(0, 0))
+	assert.Equal(t, []Index{3, 4, 6}, rangeIndexes(3, 7))
+	assert.Equal(t, []Index{4, 6, 7}, rangeIndexes(3, 7, WithExclusiveFrom(), WithInclusiveTo()))
+	assert.Equal(t, []Index{1, 2, 3}, rangeIndexes(0, 0, WithLimit(3)))
+	assert.Equal(t, []Index{10, 9, 8, 7, 6, 4, 3, 2, 1}, rangeIndexes(0, 0, WithReverse()))
+	assert.Equal(t, []Index{7, 6, 4}, rangeIndexes(3, 8, WithReverse(), WithExclusiveFrom(), WithLimit(3)))
+	assert.Equal(t, []Index{6, 4, 3}, rangeIndexes(3, 6, WithReverse(), WithInclusiveTo()))
+	assert.Equal(t, []Index{4, 3, 2, 1}, rangeIndexes(0, 5, WithReverse()))
+	assert.Equal(t, []Index{10, 9, 8}, rangeIndexes(8, 20, WithReverse()))
+	assert.Equal(t, []Index{}, rangeIndexes(11, 20, WithReverse()))
+
+	// Descending ranges ending at an index that's not in the map
+	assert.Equal(t, []Index{4, 3}, rangeIndexes(0, 5, WithReverse(), WithLimit(2)))
+	assert.Equal(t, []Index{4, 3, 2}, rangeIndexes(2, 5, WithReverse(), WithInclusiveTo()))
+	assert.Equal(t, []Index{6, 4}, rangeIndexes(4, 7, WithReverse(), WithLimit(5)))
+	assert.Equal(t, []Index{}, rangeIndexes(7, 3))
+	assert.Equal(t, []Index{}, rangeIndexes(11, 0))
+
+	_, err = m.Range(context.TODO(), 0, 0, WithLimit(-1))
+	assert.True(t, errors.IsInvalid(err))
+
+	iterator, err := m.Range(context.TODO(), 0, 0)
+	assert.NoError(t, err)
+	iterator.Close()
+	_, err = iterator.Next(context.TODO())
+	assert.True(t, errors.IsCanceled(err))
+
+	assert.NoError(t, test.Stop())
+}
+
+func TestIndexedMapRetention(t *testing.T) {
+	logging.SetLevel(logging.DebugLevel)
+
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestIndexedMapRetention",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	m, err := New(context.TODO(), "TestIndexedMapRetention", conn)
+	assert.NoError(t, err)
+
+	indexes := make([]Index, 0)
+	for i := 0; i < 10; i++ {
+		entry, err := m.Append(context.TODO(), strconv.Itoa(i), []byte(strconv.Itoa(i)))
+		assert.NoError(t, err)
+		indexes = append(indexes, entry.Index)
+	}
+
+	removed, err := m.RemoveBefore(context.TODO(), indexes[3])
+	assert.NoError(t, err)
+	assert.Equal(t, 3, removed)
+	first, err := m.FirstIndex(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, indexes[3], first)
+
+	removed, err = m.Trim(context.TODO(), 5)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, removed)
+	first, err = m.FirstIndex(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, indexes[5], first)
+
+	removed, err = m.Trim(context.TODO(), 5)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, removed)
+
+	// Concurrent trims don't remove more entries than necessary
+	for i := 10; i < 20; i++ {
+		_, err = m.Append(context.TODO(), strconv.Itoa(i), []byte(strconv.Itoa(i)))
+		assert.NoError(t, err)
+	}
+	var wg sync.WaitGroup
+	var trimmed int32
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			removed, err := m.Trim(context.TODO(), 5)
+			assert.NoError(t, err)
+			atomic.AddInt32(&trimmed, int32(removed))
+		}()
+	}
+	wg.Wait()
+	size, err := m.Len(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, 5, size)
+	assert.Equal(t, int32(10), trimmed)
+
+	_, err = NewRetainedIndexedMap(m)
+	assert.True(t, errors.IsInvalid(err))
+	_, err = NewRetainedIndexedMap(m, WithMaxAge(time.Minute))
+	assert.True(t, errors.IsInvalid(err))
+
+	retained, err := NewRetainedIndexedMap(m, WithMaxEntries(3), WithRetentionInterval(10*time.Millisecond))
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		size, err := retained.Len(context.TODO())
+		return err == nil && size == 3
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Eventually(t, func() bool {
+		return retained.Stats().Trimmed == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	size, err = retained.Len(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, 3, size)
+	assert.NoError(t, retained.Close(context.TODO()))
+
+	// Entries are aged by the time recorded in their values
+	m, err = New(context.TODO(), "TestIndexedMapRetention", conn)
+	assert.NoError(t, err)
+	assert.NoError(t, m.Clear(context.TODO()))
+	old := time.Now().Add(-time.Hour)
+	for i := 0; i < 3; i++ {
+		_, err = m.Append(context.TODO(), "old-"+strconv.Itoa(i), []byte(strconv.FormatInt(old.UnixNano(), 10)))
+		assert.NoError(t, err)
+	}
+	_, err = m.Append(context.TODO(), "new", []byte(strconv.FormatInt(time.Now().UnixNano(), 10)))
+	assert.NoError(t, err)
+
+	timestamp := func(entry Entry) (time.Time, bool) {
+		nanos, err := strconv.ParseInt(string(entry.Value), 10, 64)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return time.Unix(0, nanos), true
+	}
+	retained, err = NewRetainedIndexedMap(m, WithMaxAge(time.Minute), WithTimestampFunc(timestamp), WithRetentionInterval(10*time.Millisecond))
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		size, err := retained.Len(context.TODO())
+		return err == nil && size == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	entry, err := retained.FirstEntry(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, "new", entry.Key)
+	assert.NoError(t, retained.Close(context.TODO()))
+
+	assert.NoError(t, test.Stop())
+}
+
+func TestIndexedMapUpsertAndInsert(t *testing.T) {
+	logging.SetLevel(logging.DebugLevel)
+
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestIndexedMapUpsertAndInsert",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	m, err := New(context.TODO(), "TestIndexedMapUpsertAndInsert", conn)
+	assert.NoError(t, err)
+
+	foo, err := m.Upsert(context.TODO(), "foo", []byte("foo"))
+	assert.NoError(t, err)
+	bar, err := m.Upsert(context.TODO(), "bar", []byte("bar"))
+	assert.NoError(t, err)
+
+	// Updates keep the index of the entry
+	updated, err := m.Upsert(context.TODO(), "foo", []byte("baz"), IfMatch(foo))
+	assert.NoError(t, err)
+	assert.Equal(t, foo.Index, updated.Index)
+	assert.Equal(t, "baz", string(updated.Value))
+	first, err := m.FirstEntry(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", first.Key)
+
+	_, err = m.Upsert(context.TODO(), "foo", []byte("qux"), IfMatch(foo))
+	assert.True(t, errors.IsConflict(err))
+	_, err = m.Upsert(context.TODO(), "foo", []byte("qux"), IfNotSet())
+	assert.True(t, errors.IsConflict(err))
+
+	// Entries can only be inserted after the last entry
+	baz, err := m.InsertAfter(context.TODO(), bar.Index, "baz", []byte("baz"))
+	assert.NoError(t, err)
+	prev, err := m.PrevIndex(context.TODO(), baz.Index)
+	assert.NoError(t, err)
+	assert.Equal(t, bar.Index, prev)
+
+	_, err = m.InsertAfter(context.TODO(), foo.Index, "qux", []byte("qux"))
+	assert.True(t, errors.IsNotSupported(err))
+	_, err = m.InsertBefore(context.TODO(), bar.Index, "qux", []byte("qux"))
+	assert.True(t, errors.IsNotSupported(err))
+	_, err = m.InsertBefore(context.TODO(), baz.Index+100, "qux", []byte("qux"))
+	assert.True(t, errors.IsNotFound(err))
+	_, err = m.InsertAfter(context.TODO(), baz.Index+100, "qux", []byte("qux"))
+	assert.True(t, errors.IsNotFound(err))
+
+	size, err := m.Len(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, 3, size)
+
+	assert.NoError(t, test.Stop())
+}
+
+// insertHookClient appends a competing entry before the next Put and fails removals while failRemove is set
+type insertHookClient struct {
+	api.IndexedMapServiceClient
+	appendNext bool
+	failRemove bool
+}
+
+func (c *insertHookClient) Put(ctx context.Context, request *api.PutRequest, opts ...grpc.CallOption) (*api.PutResponse, error) {
+	if c.appendNext {
+		c.appendNext = false
+		competing := &api.PutRequest{
+			Headers: request.Headers,
+			Entry: api.Entry{
+				Position: api.Position{
+					Key: request.Entry.Key + "-competing",
+				},
+			},
+		}
+		if _, err := c.IndexedMapServiceClient.Put(ctx, competing, opts...); err != nil {
+			return nil, err
+		}
+	}
+	return c.IndexedMapServiceClient.Put(ctx, request, opts...)
+}
+
+func (c *insertHookClient) Remove(ctx context.Context, request *api.RemoveRequest, opts ...grpc.CallOption) (*api.RemoveResponse, error) {
+	if c.failRemove {
+		return nil, errors.NewUnavailable("remove failed")
+	}
+	return c.IndexedMapServiceClient.Remove(ctx, request, opts...)
+}
+
+func TestIndexedMapInsertAfterConcurrent(t *testing.T) {
+	logging.SetLevel(logging.DebugLevel)
+
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestIndexedMapInsertAfterConcurrent",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	m, err := New(context.TODO(), "TestIndexedMapInsertAfterConcurrent", conn)
+	assert.NoError(t, err)
+
+	foo, err := m.Append(context.TODO(), "foo", []byte("foo"))
+	assert.NoError(t, err)
+
+	client := &insertHookClient{
+		IndexedMapServiceClient: m.(*indexedMap).client,
+	}
+	m.(*indexedMap).client = client
+
+	// The new entry is removed when another entry is appended concurrently
+	client.appendNext = true
+	_, err = m.InsertAfter(context.TODO(), foo.Index, "bar", []byte("bar"))
+	assert.True(t, errors.IsConflict(err))
+	_, err = m.Get(context.TODO(), "bar")
+	assert.True(t, errors.IsNotFound(err))
+
+	// The removal error is returned if the new entry can't be removed
+	last, err := m.LastIndex(context.TODO())
+	assert.NoError(t, err)
+	client.appendNext = true
+	client.failRemove = true
+	_, err = m.InsertAfter(context.TODO(), last, "baz", []byte("baz"))
+	assert.True(t, errors.IsUnavailable(err))
+	_, err = m.Get(context.TODO(), "baz")
+	assert.NoError(t, err)
+
+	assert.NoError(t, test.Stop())
+}
+
+func TestIndexedMapWatchResync(t *testing.T) {
+	logging.SetLevel(logging.DebugLevel)
+
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestIndexedMapWatchResync",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	_map, err := New(context.TODO(), "TestIndexedMapWatchResync", conn)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := make(chan Event)
+	err = _map.Watch(ctx, ch, WithAutoReconnect())
+	assert.NoError(t, err)
+
+	_, err = _map.Append(context.Background(), "foo", []byte("foo"))
+	assert.NoError(t, err)
+	event := <-ch
+	assert.Equal(t, EventInsert, event.Type)
+
+	// A broken stream is re-established and signaled with a resync event
+	test.BreakStreams()
+	event = <-ch
+	assert.Equal(t, EventResync, event.Type)
+
+	_, err = _map.Append(context.Background(), "bar", []byte("bar"))
+	assert.NoError(t, err)
+	event = <-ch
+	assert.Equal(t, EventInsert, event.Type)
+	assert.Equal(t, "bar", event.Entry.Key)
+
+	assert.NoError(t, test.Stop())
+}
+
+// eventsHookClient runs a hook before watch streams are opened
+type eventsHookClient struct {
+	api.IndexedMapServiceClient
+	hook func()
+}
+
+func (c *eventsHookClient) Events(ctx context.Context, request *api.EventsRequest, opts ...grpc.CallOption) (api.IndexedMapService_EventsClient, error) {
+	if c.hook != nil {
+		c.hook()
+	}
+	return c.IndexedMapServiceClient.Events(ctx, request, opts...)
+}
+
+func TestIndexedMapWatchResyncStartIndex(t *testing.T) {
+	logging.SetLevel(logging.DebugLevel)
+
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestIndexedMapWatchResyncStartIndex",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	m, err := New(context.TODO(), "TestIndexedMapWatchResyncStartIndex", conn)
+	assert.NoError(t, err)
+
+	indexes := make([]Index, 0)
+	for i := 0; i < 4; i++ {
+		entry, err := m.Append(context.TODO(), strconv.Itoa(i), []byte(strconv.Itoa(i)))
+		assert.NoError(t, err)
+		indexes = append(indexes, entry.Index)
+	}
+
+	client := &eventsHookClient{
+		IndexedMapServiceClient: m.(*indexedMap).client,
+	}
+	m.(*indexedMap).client = client
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := make(chan Event)
+	err = m.Watch(ctx, ch, WithStartIndex(indexes[1]), WithAutoReconnect())
+	assert.NoError(t, err)
+
+	for i := 1; i < 4; i++ {
+		event := <-ch
+		assert.Equal(t, EventReplay, event.Type)
+		assert.Equal(t, indexes[i], event.Entry.Index)
+	}
+	event := <-ch
+	assert.Equal(t, EventSynced, event.Type)
+
+	// Entries removed while the stream is down are delivered with their index once the stream is re-opened
+	client.hook = func() {
+		client.hook = nil
+		_, err := m.Remove(context.TODO(), "0")
+		assert.NoError(t, err)
+		_, err = m.Remove(context.TODO(), "2")
+		assert.NoError(t, err)
+	}
+	test.BreakStreams()
+	event = <-ch
+	assert.Equal(t, EventResync, event.Type)
+	event = <-ch
+	assert.Equal(t, EventRemove, event.Type)
+	assert.Equal(t, "2", event.Entry.Key)
+	assert.Equal(t, indexes[2], event.Entry.Index)
+
+	entry, err := m.Append(context.TODO(), "4", []byte("4"))
+	assert.NoError(t, err)
+	event = <-ch
+	assert.Equal(t, EventInsert, event.Type)
+	assert.Equal(t, entry.Index, event.Entry.Index)
+
+	assert.NoError(t, test.Stop())
+}
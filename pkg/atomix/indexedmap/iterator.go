@@ -0,0 +1,98 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package indexedmap
+
+import (
+	"context"
+	api "github.com/atomix/atomix-api/go/atomix/primitive/indexedmap"
+	"github.com/atomix/atomix-go-client/pkg/atomix/primitive"
+	"github.com/atomix/atomix-go-framework/pkg/atomix/errors"
+	"io"
+)
+
+// EntryIterator iterates over the entries in a map
+type EntryIterator interface {
+	// Next returns the next entry
+	// Once all entries have been read, io.EOF is returned. If the iteration fails, the error is returned
+	// and all subsequent calls return the same error. If the context is done before the next entry is
+	// read, the iteration is aborted and the context error is returned.
+	Next(ctx context.Context) (Entry, error)
+
+	// Close closes the iterator, cancelling the underlying stream
+	Close()
+}
+
+// entryIterator is an EntryIterator that reads entries from a server stream
+type entryIterator struct {
+	stream api.IndexedMapService_EntriesClient
+	cancel context.CancelFunc
+	decode func(response *api.EntriesResponse) (Entry, error)
+	err    error
+}
+
+func (i *entryIterator) Next(ctx context.Context) (Entry, error) {
+	if i.err != nil {
+		return Entry{}, i.err
+	}
+	var response *api.EntriesResponse
+	err := primitive.RecvWithContext(ctx, i.cancel, func() error {
+		var err error
+		response, err = i.stream.Recv()
+		return err
+	})
+	if err == nil {
+		var entry Entry
+		entry, err = i.decode(response)
+		if err == nil {
+			return entry, nil
+		}
+	}
+	if err != io.EOF {
+		err = errors.From(err)
+	}
+	i.err = err
+	i.cancel()
+	return Entry{}, err
+}
+
+func (i *entryIterator) Close() {
+	if i.err == nil {
+		i.err = errors.NewCanceled("iterator is closed")
+	}
+	i.cancel()
+}
+
+// pushEntries pushes the entries read from the given iterator onto the given channel
+// The channel is closed once all entries have been read, the iteration fails, or the context is done.
+func pushEntries(ctx context.Context, iterator EntryIterator, ch chan<- Entry) {
+	defer close(ch)
+	defer iterator.Close()
+	for {
+		entry, err := iterator.Next(ctx)
+		if err == io.EOF {
+			return
+		} else if err != nil {
+			if !errors.IsCanceled(err) && !errors.IsTimeout(err) {
+				log.Errorf("Entries failed: %v", err)
+			}
+			return
+		}
+		select {
+		case ch <- entry:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
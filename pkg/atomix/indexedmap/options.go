@@ -19,6 +19,7 @@ import (
 	metaapi "github.com/atomix/atomix-api/go/atomix/primitive/meta"
 	"github.com/atomix/atomix-go-client/pkg/atomix/primitive"
 	"github.com/atomix/atomix-go-framework/pkg/atomix/meta"
+	"time"
 )
 
 // Option is a indexed map option
@@ -156,3 +157,242 @@ type Filter struct {
 	Key   string
 	Index Index
 }
+
+// WithBuffer returns a watch option that buffers events between the watch stream and the channel
+// See primitive.WatchBuffer.
+func WithBuffer(size int, policy primitive.OverflowPolicy) *BufferOption {
+	return &BufferOption{
+		WatchBuffer: primitive.NewWatchBuffer(size, policy),
+	}
+}
+
+// BufferOption is a watch option that configures the buffering of watch events
+type BufferOption struct {
+	*primitive.WatchBuffer
+}
+
+func (o *BufferOption) beforeWatch(request *api.EventsRequest) {
+}
+
+func (o *BufferOption) afterWatch(response *api.EventsResponse) {
+}
+
+// WithAutoReconnect returns a watch option that re-establishes the watch stream when it fails
+// The stream is re-opened with backoff until the watch context is done. Because the watch cannot be resumed
+// from the last revision seen, the client tracks the revision of each key and, once the stream is re-opened,
+// reads the map and sends an event for each key that changed while the stream was down. Events that were
+// already delivered are not repeated.
+// An EventResync event is sent once the stream is re-opened, before the events for the missed changes.
+func WithAutoReconnect() WatchOption {
+	return reconnectOption{}
+}
+
+type reconnectOption struct{}
+
+func (o reconnectOption) beforeWatch(request *api.EventsRequest) {
+}
+
+func (o reconnectOption) afterWatch(response *api.EventsResponse) {
+}
+
+// isAutoReconnect returns a bool indicating whether the given watch options enable auto-reconnect
+func isAutoReconnect(opts []WatchOption) bool {
+	for i := range opts {
+		if _, ok := opts[i].(reconnectOption); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// WithPrevValues returns a watch option that populates the previous entry of watch events
+// The server does not report previous entries, so the client reads the map when the watch is opened and
+// tracks the entries observed by the watch to determine the entry replaced or removed by each change.
+func WithPrevValues() WatchOption {
+	return prevValuesOption{}
+}
+
+type prevValuesOption struct{}
+
+func (o prevValuesOption) beforeWatch(request *api.EventsRequest) {
+}
+
+func (o prevValuesOption) afterWatch(response *api.EventsResponse) {
+}
+
+// isPrevValues returns a bool indicating whether the given watch options enable previous entries
+func isPrevValues(opts []WatchOption) bool {
+	for i := range opts {
+		if _, ok := opts[i].(prevValuesOption); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// WithStartIndex returns a watch option that replays the entries from the given index onward
+// The entries with an index greater than or equal to the given index are sent as EventReplay events in index
+// order, followed by a single EventSynced event once the replay is complete. Changes made after the entries
+// are read are then delivered as usual, without gaps or duplicates. Changes to entries below the start index
+// are not delivered. The start index is inclusive, so consumers resuming a watch should pass the index
+// following the last entry they processed.
+func WithStartIndex(index Index) WatchOption {
+	return startIndexOption{index: index}
+}
+
+type startIndexOption struct {
+	index Index
+}
+
+func (o startIndexOption) beforeWatch(request *api.EventsRequest) {
+	request.Replay = true
+}
+
+func (o startIndexOption) afterWatch(response *api.EventsResponse) {
+}
+
+// getStartIndex returns the index from which the given watch options replay entries
+func getStartIndex(opts []WatchOption) Index {
+	var index Index
+	for i := range opts {
+		if option, ok := opts[i].(startIndexOption); ok {
+			index = option.index
+		}
+	}
+	return index
+}
+
+// RangeOption is an option for Range calls
+type RangeOption interface {
+	applyRange(options *rangeOptions)
+}
+
+// rangeOptions is range options
+type rangeOptions struct {
+	exclusiveFrom bool
+	inclusiveTo   bool
+	reverse       bool
+	limit         int
+}
+
+func newRangeOptions(opts ...RangeOption) rangeOptions {
+	options := rangeOptions{}
+	for _, opt := range opts {
+		opt.applyRange(&options)
+	}
+	return options
+}
+
+// WithExclusiveFrom returns a range option that excludes the from index from the range
+func WithExclusiveFrom() RangeOption {
+	return exclusiveFromOption{}
+}
+
+type exclusiveFromOption struct{}
+
+func (o exclusiveFromOption) applyRange(options *rangeOptions) {
+	options.exclusiveFrom = true
+}
+
+// WithInclusiveTo returns a range option that includes the to index in the range
+func WithInclusiveTo() RangeOption {
+	return inclusiveToOption{}
+}
+
+type inclusiveToOption struct{}
+
+func (o inclusiveToOption) applyRange(options *rangeOptions) {
+	options.inclusiveTo = true
+}
+
+// WithReverse returns a range option that iterates the range in descending index order
+func WithReverse() RangeOption {
+	return reverseOption{}
+}
+
+type reverseOption struct{}
+
+func (o reverseOption) applyRange(options *rangeOptions) {
+	options.reverse = true
+}
+
+// WithLimit returns a range option that returns at most the given number of entries
+func WithLimit(limit int) RangeOption {
+	return limitOption{limit: limit}
+}
+
+type limitOption struct {
+	limit int
+}
+
+func (o limitOption) applyRange(options *rangeOptions) {
+	options.limit = o.limit
+}
+
+// RetentionOption is an option for NewRetainedIndexedMap
+type RetentionOption interface {
+	applyRetention(options *retentionOptions)
+}
+
+// retentionOptions is retention policy options
+type retentionOptions struct {
+	maxEntries int
+	maxAge     time.Duration
+	interval   time.Duration
+	timestamp  TimestampFunc
+}
+
+// WithMaxEntries returns a retention option that trims the map to the given number of entries
+func WithMaxEntries(maxEntries int) RetentionOption {
+	return maxEntriesOption{maxEntries: maxEntries}
+}
+
+type maxEntriesOption struct {
+	maxEntries int
+}
+
+func (o maxEntriesOption) applyRetention(options *retentionOptions) {
+	options.maxEntries = o.maxEntries
+}
+
+// WithMaxAge returns a retention option that removes entries older than the given age
+// The age of entries is determined by the function provided with WithTimestampFunc, which is required.
+func WithMaxAge(maxAge time.Duration) RetentionOption {
+	return maxAgeOption{maxAge: maxAge}
+}
+
+type maxAgeOption struct {
+	maxAge time.Duration
+}
+
+func (o maxAgeOption) applyRetention(options *retentionOptions) {
+	options.maxAge = o.maxAge
+}
+
+// WithRetentionInterval returns a retention option that sets the interval at which the policy is applied
+func WithRetentionInterval(interval time.Duration) RetentionOption {
+	return retentionIntervalOption{interval: interval}
+}
+
+type retentionIntervalOption struct {
+	interval time.Duration
+}
+
+func (o retentionIntervalOption) applyRetention(options *retentionOptions) {
+	options.interval = o.interval
+}
+
+// WithTimestampFunc returns a retention option that determines the age of entries with the given function
+// Entries carry no write time in their metadata, so the function must read the time from the entry, for example
+// from its value.
+func WithTimestampFunc(f TimestampFunc) RetentionOption {
+	return timestampFuncOption{f: f}
+}
+
+type timestampFuncOption struct {
+	f TimestampFunc
+}
+
+func (o timestampFuncOption) applyRetention(options *retentionOptions) {
+	options.timestamp = o.f
+}
@@ -34,4 +34,10 @@ func TestOptions(t *testing.T) {
 	assert.False(t, eventRequest.Replay)
 	WithReplay().beforeWatch(eventRequest)
 	assert.True(t, eventRequest.Replay)
+
+	eventRequest = &api.EventsRequest{}
+	WithStartIndex(10).beforeWatch(eventRequest)
+	assert.True(t, eventRequest.Replay)
+	assert.Equal(t, Index(10), getStartIndex([]WatchOption{WithStartIndex(10)}))
+	assert.Equal(t, Index(0), getStartIndex([]WatchOption{WithReplay()}))
 }
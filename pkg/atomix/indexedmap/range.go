@@ -0,0 +1,170 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package indexedmap
+
+import (
+	"context"
+	"github.com/atomix/atomix-go-framework/pkg/atomix/errors"
+	"io"
+)
+
+func (m *indexedMap) Range(ctx context.Context, from, to Index, opts ...RangeOption) (EntryIterator, error) {
+	options := newRangeOptions(opts...)
+	if options.limit < 0 {
+		return nil, errors.NewInvalid("range limit must not be negative")
+	}
+
+	// Normalize the bounds to the half-open range [start, end), where an end of 0 is unbounded
+	start, end := from, to
+	if options.exclusiveFrom {
+		start++
+	}
+	if options.inclusiveTo && end > 0 {
+		end++
+	}
+	iterator := &rangeIterator{
+		m:       m,
+		start:   start,
+		end:     end,
+		options: options,
+	}
+	if end > 0 && start >= end {
+		iterator.done = true
+	}
+	return iterator, nil
+}
+
+// rangeIterator is an EntryIterator over a range of indexes
+// Ranges are read from a single entries stream, which is closed once the end of the range is reached.
+// Descending ranges are read in ascending order when the first entry is requested and returned in reverse. If a
+// limit is set, only the last entries in the range up to the limit are kept.
+type rangeIterator struct {
+	m        *indexedMap
+	start    Index
+	end      Index
+	options  rangeOptions
+	iterator EntryIterator
+	reversed []Entry
+	count    int
+	done     bool
+	err      error
+}
+
+func (i *rangeIterator) Next(ctx context.Context) (Entry, error) {
+	if i.err != nil {
+		return Entry{}, i.err
+	}
+	if i.done || (i.options.limit > 0 && i.count >= i.options.limit) {
+		i.close()
+		return Entry{}, io.EOF
+	}
+	var entry *Entry
+	var err error
+	if i.options.reverse {
+		entry, err = i.nextReverse(ctx)
+	} else {
+		entry, err = i.nextForward(ctx)
+	}
+	if err != nil {
+		i.close()
+		i.err = err
+		return Entry{}, err
+	}
+	if entry == nil {
+		i.close()
+		i.done = true
+		return Entry{}, io.EOF
+	}
+	i.count++
+	return *entry, nil
+}
+
+// nextForward reads the next entry in ascending index order from the entries stream
+// Entries below the start of the range are skipped, and nil is returned once the end of the range is reached.
+func (i *rangeIterator) nextForward(ctx context.Context) (*Entry, error) {
+	if i.iterator == nil {
+		iterator, err := i.m.Iterate(ctx)
+		if err != nil {
+			return nil, err
+		}
+		i.iterator = iterator
+	}
+	for {
+		entry, err := i.iterator.Next(ctx)
+		if err == io.EOF {
+			return nil, nil
+		} else if err != nil {
+			return nil, err
+		}
+		if entry.Index < i.start {
+			continue
+		}
+		if i.end > 0 && entry.Index >= i.end {
+			return nil, nil
+		}
+		return &entry, nil
+	}
+}
+
+// nextReverse reads the next entry in descending index order
+// The range is read from the entries stream when the first entry is requested. If a limit is set, the entries
+// are read into a ring buffer holding the last entries in the range up to the limit.
+func (i *rangeIterator) nextReverse(ctx context.Context) (*Entry, error) {
+	if i.reversed == nil {
+		var buffer []Entry
+		read := 0
+		for {
+			entry, err := i.nextForward(ctx)
+			if err != nil {
+				return nil, err
+			}
+			if entry == nil {
+				break
+			}
+			if i.options.limit > 0 && len(buffer) == i.options.limit {
+				buffer[read%i.options.limit] = *entry
+			} else {
+				buffer = append(buffer, *entry)
+			}
+			read++
+		}
+		i.close()
+		i.reversed = make([]Entry, 0, len(buffer))
+		for n := read - 1; n >= read-len(buffer); n-- {
+			i.reversed = append(i.reversed, buffer[n%len(buffer)])
+		}
+	}
+	if len(i.reversed) == 0 {
+		return nil, nil
+	}
+	entry := i.reversed[0]
+	i.reversed = i.reversed[1:]
+	return &entry, nil
+}
+
+// close closes the entries stream, if any
+func (i *rangeIterator) close() {
+	if i.iterator != nil {
+		i.iterator.Close()
+		i.iterator = nil
+	}
+}
+
+func (i *rangeIterator) Close() {
+	i.close()
+	if i.err == nil {
+		i.err = errors.NewCanceled("iterator is closed")
+	}
+}
@@ -0,0 +1,257 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package indexedmap
+
+import (
+	"context"
+	"github.com/atomix/atomix-go-framework/pkg/atomix/errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const defaultRetentionInterval = time.Minute
+
+func (m *indexedMap) RemoveBefore(ctx context.Context, index Index) (int, error) {
+	if index <= 1 {
+		return 0, nil
+	}
+	iterator, err := m.Range(ctx, 0, index)
+	if err != nil {
+		return 0, err
+	}
+	defer iterator.Close()
+
+	// Entries removed concurrently by other clients are skipped and not counted
+	removed := 0
+	for {
+		entry, err := iterator.Next(ctx)
+		if err == io.EOF {
+			return removed, nil
+		} else if err != nil {
+			return removed, err
+		}
+		if _, err := m.RemoveIndex(ctx, entry.Index); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return removed, err
+		}
+		removed++
+	}
+}
+
+func (m *indexedMap) Trim(ctx context.Context, maxEntries int) (int, error) {
+	if maxEntries < 0 {
+		return 0, errors.NewInvalid("max entries must not be negative")
+	}
+	iterator, err := m.Range(ctx, 0, 0)
+	if err != nil {
+		return 0, err
+	}
+	defer iterator.Close()
+
+	// The size of the map is checked before each removal so that clients trimming the map concurrently don't
+	// remove more entries than necessary. Entries removed concurrently by other clients are skipped and not counted.
+	removed := 0
+	for {
+		size, err := m.Len(ctx)
+		if err != nil {
+			return removed, err
+		}
+		if size <= maxEntries {
+			return removed, nil
+		}
+		entry, err := iterator.Next(ctx)
+		if err == io.EOF {
+			return removed, nil
+		} else if err != nil {
+			return removed, err
+		}
+		if _, err := m.RemoveIndex(ctx, entry.Index); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return removed, err
+		}
+		removed++
+	}
+}
+
+// TimestampFunc returns the time at which the given entry was written
+// Returns false if the time is not known.
+type TimestampFunc func(entry Entry) (time.Time, bool)
+
+// RetainedIndexedMap is an IndexedMap that trims its oldest entries in the background
+type RetainedIndexedMap interface {
+	IndexedMap
+
+	// Stats returns the retention statistics
+	Stats() RetentionStats
+}
+
+// RetentionStats is a snapshot of the statistics of a retention policy
+type RetentionStats struct {
+	// Runs is the number of times the retention policy has been applied
+	Runs uint64
+
+	// Trimmed is the number of entries removed by the retention policy
+	Trimmed uint64
+
+	// Failures is the number of times the retention policy failed to be applied
+	Failures uint64
+}
+
+// NewRetainedIndexedMap creates a new RetainedIndexedMap that applies a retention policy to the given map
+// The policy is applied periodically, removing entries from the first index until the map holds no more than
+// the maximum number of entries and its first entry is younger than the maximum age. Entries carry no write time
+// in their metadata, so trimming by age requires a TimestampFunc to determine the age of an entry, and stops at
+// the first entry with no known timestamp.
+// Closing the retained map stops the retention policy and closes the underlying map.
+func NewRetainedIndexedMap(m IndexedMap, opts ...RetentionOption) (RetainedIndexedMap, error) {
+	options := retentionOptions{
+		interval: defaultRetentionInterval,
+	}
+	for i := range opts {
+		opts[i].applyRetention(&options)
+	}
+	if options.maxEntries <= 0 && options.maxAge <= 0 {
+		return nil, errors.NewInvalid("retention policy requires a maximum number of entries or a maximum age")
+	}
+	if options.maxAge > 0 && options.timestamp == nil {
+		return nil, errors.NewInvalid("retention by age requires a timestamp function")
+	}
+	if options.interval <= 0 {
+		return nil, errors.NewInvalid("retention interval must be positive")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	retained := &retainedIndexedMap{
+		IndexedMap: m,
+		options:    options,
+		cancel:     cancel,
+	}
+	retained.wg.Add(1)
+	go retained.run(ctx)
+	return retained, nil
+}
+
+// retainedIndexedMap is an IndexedMap that applies a retention policy in the background
+type retainedIndexedMap struct {
+	IndexedMap
+	options  retentionOptions
+	runs     uint64
+	trimmed  uint64
+	failures uint64
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+// run applies the retention policy at the configured interval until the context is done
+func (m *retainedIndexedMap) run(ctx context.Context) {
+	defer m.wg.Done()
+	ticker := time.NewTicker(m.options.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			removed, err := m.apply(ctx)
+			atomic.AddUint64(&m.runs, 1)
+			atomic.AddUint64(&m.trimmed, uint64(removed))
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				atomic.AddUint64(&m.failures, 1)
+				log.Warnf("Failed to apply retention policy to '%s': %v", m.Name(), err)
+			} else if removed > 0 {
+				log.Debugf("Trimmed %d entries from '%s'", removed, m.Name())
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// apply applies the retention policy, returning the number of entries removed
+func (m *retainedIndexedMap) apply(ctx context.Context) (int, error) {
+	removed := 0
+	if m.options.maxEntries > 0 {
+		n, err := m.Trim(ctx, m.options.maxEntries)
+		removed += n
+		if err != nil {
+			return removed, err
+		}
+	}
+	if m.options.maxAge > 0 {
+		n, err := m.trimExpired(ctx)
+		removed += n
+		if err != nil {
+			return removed, err
+		}
+	}
+	return removed, nil
+}
+
+// trimExpired removes entries older than the maximum age from the first index
+func (m *retainedIndexedMap) trimExpired(ctx context.Context) (int, error) {
+	iterator, err := m.Range(ctx, 0, 0)
+	if err != nil {
+		return 0, err
+	}
+	defer iterator.Close()
+
+	removed := 0
+	for {
+		entry, err := iterator.Next(ctx)
+		if err == io.EOF {
+			return removed, nil
+		} else if err != nil {
+			return removed, err
+		}
+		timestamp, ok := m.options.timestamp(entry)
+		if !ok || time.Since(timestamp) < m.options.maxAge {
+			return removed, nil
+		}
+		if _, err := m.RemoveIndex(ctx, entry.Index); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return removed, err
+		}
+		removed++
+	}
+}
+
+func (m *retainedIndexedMap) Stats() RetentionStats {
+	return RetentionStats{
+		Runs:     atomic.LoadUint64(&m.runs),
+		Trimmed:  atomic.LoadUint64(&m.trimmed),
+		Failures: atomic.LoadUint64(&m.failures),
+	}
+}
+
+func (m *retainedIndexedMap) Close(ctx context.Context) error {
+	m.cancel()
+	m.wg.Wait()
+	return m.IndexedMap.Close(ctx)
+}
+
+func (m *retainedIndexedMap) Delete(ctx context.Context) error {
+	m.cancel()
+	m.wg.Wait()
+	return m.IndexedMap.Delete(ctx)
+}
@@ -0,0 +1,238 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package indexedmap
+
+import (
+	"context"
+	api "github.com/atomix/atomix-api/go/atomix/primitive/indexedmap"
+	"github.com/atomix/atomix-go-client/pkg/atomix/primitive"
+	"github.com/atomix/atomix-go-framework/pkg/atomix/meta"
+	"io"
+)
+
+func newRevisionTracker() *revisionTracker {
+	return &revisionTracker{
+		revisions: make(map[string]meta.Revision),
+		indexes:   make(map[string]Index),
+	}
+}
+
+// revisionTracker tracks the revision and index of each key in the map as observed by a watch
+// Once the watch has been resynchronized, the tracked revisions are exactly the state delivered to the
+// consumer, and events already reflected in that state are discarded as duplicates. The tracked indexes are
+// used to populate the removals of keys that were missed while the watch was down.
+type revisionTracker struct {
+	revisions map[string]meta.Revision
+	indexes   map[string]Index
+	dedupe    bool
+}
+
+// update updates the tracked revisions with the given event, returning false if the event is a duplicate
+func (t *revisionTracker) update(event Event) bool {
+	key := event.Entry.Key
+	revision, ok := t.revisions[key]
+	switch event.Type {
+	case EventRemove:
+		if t.dedupe && (!ok || event.Entry.Revision < revision) {
+			return false
+		}
+		delete(t.revisions, key)
+		delete(t.indexes, key)
+	default:
+		if ok && event.Entry.Revision <= revision {
+			return !t.dedupe
+		}
+		t.revisions[key] = event.Entry.Revision
+		t.indexes[key] = event.Entry.Index
+	}
+	return true
+}
+
+func newEntryView() *entryView {
+	return &entryView{
+		entries: make(map[string]Entry),
+	}
+}
+
+// entryView tracks the entries in the map as observed by a watch to populate the previous entry of events
+type entryView struct {
+	entries map[string]Entry
+}
+
+// update applies the given event to the view and sets the previous entry of the event
+// Events older than the tracked entry for the key precede the view; they're given no previous entry
+// and are not applied.
+func (v *entryView) update(event *Event) {
+	key := event.Entry.Key
+	prev, ok := v.entries[key]
+	if ok && event.Entry.Revision < prev.Revision {
+		return
+	}
+	switch event.Type {
+	case EventRemove:
+		if ok {
+			event.PrevEntry = &prev
+		}
+		delete(v.entries, key)
+	default:
+		if ok && event.Entry.Revision == prev.Revision {
+			return
+		}
+		if ok {
+			event.PrevEntry = &prev
+		}
+		v.entries[key] = event.Entry
+	}
+}
+
+// readEntries reads all the entries in the map, or the entry for the key watched by the given request
+func (m *indexedMap) readEntries(ctx context.Context, request *api.EventsRequest) ([]Entry, error) {
+	iterator, err := m.Iterate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer iterator.Close()
+	entries := make([]Entry, 0)
+	for {
+		entry, err := iterator.Next(ctx)
+		if err == io.EOF {
+			return entries, nil
+		} else if err != nil {
+			return nil, err
+		}
+		if isWatched(request, entry) {
+			entries = append(entries, entry)
+		}
+	}
+}
+
+// isWatched returns whether the given entry is watched by the given request
+func isWatched(request *api.EventsRequest, entry Entry) bool {
+	return (request.Pos.Key == "" || entry.Key == request.Pos.Key) && (request.Pos.Index == 0 || entry.Index == Index(request.Pos.Index))
+}
+
+// replay sends the entries in the map from the given index onward as EventReplay events in index order
+// The entries are read once the watch stream is open, so changes made after the entries are read are delivered
+// by the stream. The entries read are tracked to discard events for changes made before they were read.
+func (m *indexedMap) replay(ctx context.Context, request *api.EventsRequest, start Index, tracker *revisionTracker, send func(Event) bool) error {
+	iterator, err := m.Range(ctx, start, 0)
+	if err != nil {
+		return err
+	}
+	defer iterator.Close()
+	for {
+		entry, err := iterator.Next(ctx)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		if !isWatched(request, entry) {
+			continue
+		}
+		tracker.revisions[entry.Key] = entry.Revision
+		tracker.indexes[entry.Key] = entry.Index
+		if !send(Event{Type: EventReplay, Entry: entry}) {
+			return ctx.Err()
+		}
+	}
+	tracker.dedupe = true
+	return nil
+}
+
+// resync re-establishes a failed watch stream
+// The stream is re-opened and the entries in the map are compared to the tracked revisions to send the
+// changes that were missed while the stream was down. Intermediate changes to a key are collapsed into a
+// single event, and keys removed while the stream was down are sent as EventRemove events with the last index
+// observed for the key and no value.
+// An EventResync event is sent before any of these events.
+// The new stream and a function to cancel it are returned once the watch has been resynchronized.
+func (m *indexedMap) resync(ctx context.Context, request *api.EventsRequest, tracker *revisionTracker, send func(Event) bool) (api.IndexedMapService_EventsClient, context.CancelFunc, error) {
+	request = &api.EventsRequest{
+		Headers: request.Headers,
+		Pos:     request.Pos,
+	}
+
+	var stream api.IndexedMapService_EventsClient
+	var cancel context.CancelFunc
+	var entries []Entry
+	err := primitive.RetryWithBackoff(ctx, func() error {
+		var streamCtx context.Context
+		streamCtx, cancel = context.WithCancel(ctx)
+		var err error
+		stream, err = m.client.Events(streamCtx, request)
+		if err != nil {
+			cancel()
+			log.Warnf("Failed to reconnect watch: %v", err)
+			return err
+		}
+		entries, err = m.readEntries(ctx, request)
+		if err != nil {
+			cancel()
+			log.Warnf("Failed to resynchronize watch: %v", err)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !send(Event{Type: EventResync}) {
+		cancel()
+		return nil, nil, ctx.Err()
+	}
+
+	revisions := make(map[string]meta.Revision)
+	indexes := make(map[string]Index)
+	for _, entry := range entries {
+		revisions[entry.Key] = entry.Revision
+		indexes[entry.Key] = entry.Index
+		revision, ok := tracker.revisions[entry.Key]
+		if !ok {
+			if !send(Event{Type: EventInsert, Entry: entry}) {
+				cancel()
+				return nil, nil, ctx.Err()
+			}
+		} else if revision != entry.Revision {
+			if !send(Event{Type: EventUpdate, Entry: entry}) {
+				cancel()
+				return nil, nil, ctx.Err()
+			}
+		}
+	}
+	for key, revision := range tracker.revisions {
+		if _, ok := revisions[key]; !ok {
+			event := Event{
+				Type: EventRemove,
+				Entry: Entry{
+					ObjectMeta: meta.ObjectMeta{
+						Revision: revision,
+					},
+					Key:   key,
+					Index: tracker.indexes[key],
+				},
+			}
+			if !send(event) {
+				cancel()
+				return nil, nil, ctx.Err()
+			}
+		}
+	}
+	tracker.revisions = revisions
+	tracker.indexes = indexes
+	tracker.dedupe = true
+	return stream, cancel, nil
+}
@@ -0,0 +1,98 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package list
+
+import (
+	"context"
+	api "github.com/atomix/atomix-api/go/atomix/primitive/list"
+	"github.com/atomix/atomix-go-client/pkg/atomix/primitive"
+	"github.com/atomix/atomix-go-framework/pkg/atomix/errors"
+	"io"
+)
+
+// ItemIterator iterates over the values in a list
+type ItemIterator interface {
+	// Next returns the next value
+	// Once all values have been read, io.EOF is returned. If the iteration fails, the error is returned
+	// and all subsequent calls return the same error. If the context is done before the next value is
+	// read, the iteration is aborted and the context error is returned.
+	Next(ctx context.Context) ([]byte, error)
+
+	// Close closes the iterator, cancelling the underlying stream
+	Close()
+}
+
+// itemIterator is an ItemIterator that reads values from a server stream
+type itemIterator struct {
+	stream api.ListService_ElementsClient
+	cancel context.CancelFunc
+	decode func(response *api.ElementsResponse) ([]byte, error)
+	err    error
+}
+
+func (i *itemIterator) Next(ctx context.Context) ([]byte, error) {
+	if i.err != nil {
+		return nil, i.err
+	}
+	var response *api.ElementsResponse
+	err := primitive.RecvWithContext(ctx, i.cancel, func() error {
+		var err error
+		response, err = i.stream.Recv()
+		return err
+	})
+	if err == nil {
+		var value []byte
+		value, err = i.decode(response)
+		if err == nil {
+			return value, nil
+		}
+	}
+	if err != io.EOF {
+		err = errors.From(err)
+	}
+	i.err = err
+	i.cancel()
+	return nil, err
+}
+
+func (i *itemIterator) Close() {
+	if i.err == nil {
+		i.err = errors.NewCanceled("iterator is closed")
+	}
+	i.cancel()
+}
+
+// pushItems pushes the values read from the given iterator onto the given channel
+// The channel is closed once all values have been read, the iteration fails, or the context is done.
+func pushItems(ctx context.Context, iterator ItemIterator, ch chan<- []byte) {
+	defer close(ch)
+	defer iterator.Close()
+	for {
+		value, err := iterator.Next(ctx)
+		if err == io.EOF {
+			return
+		} else if err != nil {
+			if !errors.IsCanceled(err) && !errors.IsTimeout(err) {
+				log.Errorf("Items failed: %v", err)
+			}
+			return
+		}
+		select {
+		case ch <- value:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/atomix/atomix-go-framework/pkg/atomix/logging"
 	"google.golang.org/grpc"
 	"io"
+	"strconv"
 )
 
 var log = logging.GetLogger("atomix", "client", "list")
@@ -60,6 +61,10 @@ type List interface {
 	// Len gets the length of the list
 	Len(ctx context.Context) (int, error)
 
+	// Iterate returns an iterator over the values in the list
+	// The iterator must be closed once the caller is done with it.
+	Iterate(ctx context.Context) (ItemIterator, error)
+
 	// Items iterates through the values in the list
 	// This is a non-blocking method. If the method returns without error, values will be pushed on to the
 	// given channel and the channel will be closed once all values have been read from the list.
@@ -86,6 +91,13 @@ const (
 
 	// EventReplay indicates a value was replayed
 	EventReplay EventType = "replay"
+
+	// EventResync indicates the watch was re-established and events may have been missed
+	// A resync event is followed by the replay of the values in the list and a synced event.
+	EventResync EventType = "resync"
+
+	// EventSynced indicates all the values in the list have been replayed
+	EventSynced EventType = "synced"
 )
 
 // Event is a list change event
@@ -126,14 +138,36 @@ type list struct {
 	options newListOptions
 }
 
+// encode encrypts the given value if necessary and encodes it for storage in the list
+func (l *list) encode(value []byte) (string, error) {
+	value, err := l.Encrypt(value)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(value), nil
+}
+
+// decode decodes the given stored value and decrypts it if necessary
+func (l *list) decode(value string) ([]byte, error) {
+	bytes, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	return l.Decrypt(bytes)
+}
+
 func (l *list) Append(ctx context.Context, value []byte) error {
+	encoded, err := l.encode(value)
+	if err != nil {
+		return err
+	}
 	request := &api.AppendRequest{
 		Headers: l.GetHeaders(),
 		Value: api.Value{
-			Value: base64.StdEncoding.EncodeToString(value),
+			Value: encoded,
 		},
 	}
-	_, err := l.client.Append(ctx, request)
+	_, err = l.client.Append(ctx, request)
 	if err != nil {
 		return errors.From(err)
 	}
@@ -141,16 +175,20 @@ func (l *list) Append(ctx context.Context, value []byte) error {
 }
 
 func (l *list) Insert(ctx context.Context, index int, value []byte) error {
+	encoded, err := l.encode(value)
+	if err != nil {
+		return err
+	}
 	request := &api.InsertRequest{
 		Headers: l.GetHeaders(),
 		Item: api.Item{
 			Index: uint32(index),
 			Value: api.Value{
-				Value: base64.StdEncoding.EncodeToString(value),
+				Value: encoded,
 			},
 		},
 	}
-	_, err := l.client.Insert(ctx, request)
+	_, err = l.client.Insert(ctx, request)
 	if err != nil {
 		return errors.From(err)
 	}
@@ -158,16 +196,20 @@ func (l *list) Insert(ctx context.Context, index int, value []byte) error {
 }
 
 func (l *list) Set(ctx context.Context, index int, value []byte) error {
+	encoded, err := l.encode(value)
+	if err != nil {
+		return err
+	}
 	request := &api.SetRequest{
 		Headers: l.GetHeaders(),
 		Item: api.Item{
 			Index: uint32(index),
 			Value: api.Value{
-				Value: base64.StdEncoding.EncodeToString(value),
+				Value: encoded,
 			},
 		},
 	}
-	_, err := l.client.Set(ctx, request)
+	_, err = l.client.Set(ctx, request)
 	if err != nil {
 		return errors.From(err)
 	}
@@ -183,7 +225,7 @@ func (l *list) Get(ctx context.Context, index int) ([]byte, error) {
 	if err != nil {
 		return nil, errors.From(err)
 	}
-	return base64.StdEncoding.DecodeString(response.Item.Value.Value)
+	return l.decode(response.Item.Value.Value)
 }
 
 func (l *list) Remove(ctx context.Context, index int) ([]byte, error) {
@@ -195,7 +237,7 @@ func (l *list) Remove(ctx context.Context, index int) ([]byte, error) {
 	if err != nil {
 		return nil, errors.From(err)
 	}
-	return base64.StdEncoding.DecodeString(response.Item.Value.Value)
+	return l.decode(response.Item.Value.Value)
 }
 
 func (l *list) Len(ctx context.Context) (int, error) {
@@ -209,37 +251,39 @@ func (l *list) Len(ctx context.Context) (int, error) {
 	return int(response.Size_), nil
 }
 
-func (l *list) Items(ctx context.Context, ch chan<- []byte) error {
+func (l *list) Iterate(ctx context.Context) (ItemIterator, error) {
 	request := &api.ElementsRequest{
 		Headers: l.GetHeaders(),
 	}
+	ctx, cancel := context.WithCancel(ctx)
 	stream, err := l.client.Elements(ctx, request)
 	if err != nil {
-		return errors.From(err)
+		cancel()
+		return nil, errors.From(err)
 	}
+	return &itemIterator{
+		stream: stream,
+		cancel: cancel,
+		decode: func(response *api.ElementsResponse) ([]byte, error) {
+			return l.decode(response.Item.Value.Value)
+		},
+	}, nil
+}
 
-	go func() {
-		defer close(ch)
-		for {
-			response, err := stream.Recv()
-			if err == io.EOF {
-				return
-			} else if err != nil {
-				log.Errorf("Entries failed: %v", err)
-			} else {
-				bytes, err := base64.StdEncoding.DecodeString(response.Item.Value.Value)
-				if err != nil {
-					log.Errorf("Failed to decode list item: %v", err)
-				} else {
-					ch <- bytes
-				}
-			}
-		}
-	}()
+func (l *list) Items(ctx context.Context, ch chan<- []byte) error {
+	iterator, err := l.Iterate(ctx)
+	if err != nil {
+		return err
+	}
+	go pushItems(ctx, iterator, ch)
 	return nil
 }
 
 func (l *list) Watch(ctx context.Context, ch chan<- Event, opts ...WatchOption) error {
+	// Events are keyed by index, and indexes shift as items are inserted and removed
+	if config := primitive.GetWatchBuffer(opts); config != nil && config.Policy == primitive.OverflowCoalesce {
+		return errors.NewNotSupported("list watches do not support coalescing events")
+	}
 	request := &api.EventsRequest{
 		Headers: l.GetHeaders(),
 	}
@@ -247,20 +291,49 @@ func (l *list) Watch(ctx context.Context, ch chan<- Event, opts ...WatchOption)
 		opts[i].beforeWatch(request)
 	}
 
+	// Replay is performed by the client so the end of the replay can be signaled to the consumer
+	replay := request.Replay
+	request.Replay = false
+
 	stream, err := l.client.Events(ctx, request)
 	if err != nil {
 		return errors.From(err)
 	}
 
+	reconnect := isAutoReconnect(opts)
+
+	buffer := primitive.NewEventBuffer(primitive.GetWatchBuffer(opts))
+	go buffer.Forward(ctx, ch)
+
+	send := func(event Event) bool {
+		return buffer.Push(ctx, strconv.Itoa(event.Index), event)
+	}
+
+	sync := func() bool {
+		if err := l.replay(ctx, send); err != nil {
+			if ctx.Err() == nil {
+				log.Errorf("Watch failed: %v", err)
+			}
+			return false
+		}
+		return buffer.Push(ctx, "", Event{Type: EventSynced})
+	}
 	openCh := make(chan struct{})
 	go func() {
-		defer close(ch)
+		defer buffer.Close()
 		open := false
 		defer func() {
 			if !open {
 				close(openCh)
 			}
 		}()
+		cancel := func() {}
+		defer func() {
+			cancel()
+		}()
+		// The list is replayed once the stream has delivered its first response, so changes made after the
+		// values are read are delivered by the stream
+		syncing := replay
 		for {
 			response, err := stream.Recv()
 			if err == io.EOF ||
@@ -268,39 +341,64 @@ func (l *list) Watch(ctx context.Context, ch chan<- Event, opts ...WatchOption)
 				errors.IsTimeout(errors.From(err)) {
 				return
 			} else if err != nil {
-				log.Errorf("Watch failed: %v", err)
-				return
+				if !reconnect {
+					log.Errorf("Watch failed: %v", err)
+					return
+				}
+				log.Warnf("Watch failed; reconnecting: %v", err)
+				cancel()
+				stream, cancel, err = l.resync(ctx, request)
+				if err != nil {
+					cancel = func() {}
+					return
+				}
+				if !buffer.Push(ctx, "", Event{Type: EventResync}) {
+					return
+				}
+				syncing = true
 			} else {
 				if !open {
 					close(openCh)
 					open = true
 				}
+				if syncing {
+					syncing = false
+					if !sync() {
+						return
+					}
+				}
 				for i := range opts {
 					opts[i].afterWatch(response)
 				}
 
-				bytes, err := base64.StdEncoding.DecodeString(response.Event.Item.Value.Value)
+				bytes, err := l.decode(response.Event.Item.Value.Value)
 				if err != nil {
 					log.Errorf("Failed to decode list item: %v", err)
 				} else {
 					switch response.Event.Type {
 					case api.Event_ADD:
-						ch <- Event{
+						if !send(Event{
 							Type:  EventAdd,
 							Index: int(response.Event.Item.Index),
 							Value: bytes,
+						}) {
+							return
 						}
 					case api.Event_REMOVE:
-						ch <- Event{
+						if !send(Event{
 							Type:  EventRemove,
 							Index: int(response.Event.Item.Index),
 							Value: bytes,
+						}) {
+							return
 						}
 					case api.Event_REPLAY:
-						ch <- Event{
+						if !send(Event{
 							Type:  EventReplay,
 							Index: int(response.Event.Item.Index),
 							Value: bytes,
+						}) {
+							return
 						}
 					}
 				}
@@ -17,11 +17,18 @@ package list
 import (
 	"context"
 	primitiveapi "github.com/atomix/atomix-api/go/atomix/primitive"
+	api "github.com/atomix/atomix-api/go/atomix/primitive/list"
+	"github.com/atomix/atomix-go-client/pkg/atomix/primitive"
 	"github.com/atomix/atomix-go-client/pkg/atomix/util/test"
 	"github.com/atomix/atomix-go-framework/pkg/atomix/errors"
 	"github.com/atomix/atomix-go-framework/pkg/atomix/logging"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"io"
+	"strconv"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestListOperations(t *testing.T) {
@@ -187,3 +194,292 @@ func TestListOperations(t *testing.T) {
 
 	assert.NoError(t, test.Stop())
 }
+
+func TestListIterator(t *testing.T) {
+	logging.SetLevel(logging.DebugLevel)
+
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestListIterator",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	list, err := New(context.TODO(), "TestListIterator", conn)
+	assert.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		err = list.Append(context.Background(), []byte(strconv.Itoa(i)))
+		assert.NoError(t, err)
+	}
+
+	iterator, err := list.Iterate(context.Background())
+	assert.NoError(t, err)
+	elements := make([]string, 0)
+	for {
+		value, err := iterator.Next(context.Background())
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		elements = append(elements, string(value))
+	}
+	assert.Equal(t, []string{"0", "1", "2"}, elements)
+	_, err = iterator.Next(context.Background())
+	assert.Equal(t, io.EOF, err)
+	iterator.Close()
+
+	iterator, err = list.Iterate(context.Background())
+	assert.NoError(t, err)
+	_, err = iterator.Next(context.Background())
+	assert.NoError(t, err)
+	iterator.Close()
+	_, err = iterator.Next(context.Background())
+	assert.True(t, errors.IsCanceled(err))
+
+	iterator, err = list.Iterate(context.Background())
+	assert.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = iterator.Next(ctx)
+	assert.True(t, errors.IsCanceled(err))
+	_, err = iterator.Next(context.Background())
+	assert.True(t, errors.IsCanceled(err))
+	iterator.Close()
+
+	assert.NoError(t, test.Stop())
+}
+
+func TestListWatchSynced(t *testing.T) {
+	logging.SetLevel(logging.DebugLevel)
+
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestListWatchSynced",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	list, err := New(context.TODO(), "TestListWatchSynced", conn)
+	assert.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		err = list.Append(context.Background(), []byte(strconv.Itoa(i)))
+		assert.NoError(t, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := make(chan Event)
+	err = list.Watch(ctx, ch, WithReplay())
+	assert.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		event := <-ch
+		assert.Equal(t, EventReplay, event.Type)
+		assert.Equal(t, i, event.Index)
+		assert.Equal(t, strconv.Itoa(i), string(event.Value))
+	}
+	event := <-ch
+	assert.Equal(t, EventSynced, event.Type)
+
+	err = list.Append(context.Background(), []byte("3"))
+	assert.NoError(t, err)
+	event = <-ch
+	assert.Equal(t, EventAdd, event.Type)
+	assert.Equal(t, 3, event.Index)
+
+	err = list.Watch(ctx, make(chan Event), WithBuffer(10, primitive.OverflowCoalesce))
+	assert.True(t, errors.IsNotSupported(err))
+
+	assert.NoError(t, test.Stop())
+}
+
+func TestListWatchResync(t *testing.T) {
+	logging.SetLevel(logging.DebugLevel)
+
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestListWatchResync",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	list, err := New(context.TODO(), "TestListWatchResync", conn)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := make(chan Event)
+	err = list.Watch(ctx, ch, WithAutoReconnect())
+	assert.NoError(t, err)
+
+	err = list.Append(context.Background(), []byte("0"))
+	assert.NoError(t, err)
+	event := <-ch
+	assert.Equal(t, EventAdd, event.Type)
+
+	// A broken stream is re-established and followed by a replay of the list
+	test.BreakStreams()
+	event = <-ch
+	assert.Equal(t, EventResync, event.Type)
+	event = <-ch
+	assert.Equal(t, EventReplay, event.Type)
+	assert.Equal(t, "0", string(event.Value))
+	event = <-ch
+	assert.Equal(t, EventSynced, event.Type)
+
+	err = list.Append(context.Background(), []byte("1"))
+	assert.NoError(t, err)
+	event = <-ch
+	assert.Equal(t, EventAdd, event.Type)
+	assert.Equal(t, 1, event.Index)
+
+	assert.NoError(t, test.Stop())
+}
+
+// replayHookClient runs a hook before the values in the list are read
+type replayHookClient struct {
+	api.ListServiceClient
+	hook func()
+}
+
+func (c *replayHookClient) Elements(ctx context.Context, request *api.ElementsRequest, opts ...grpc.CallOption) (api.ListService_ElementsClient, error) {
+	c.hook()
+	return c.ListServiceClient.Elements(ctx, request, opts...)
+}
+
+func TestListWatchReplayConcurrent(t *testing.T) {
+	logging.SetLevel(logging.DebugLevel)
+
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestListWatchReplayConcurrent",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	l, err := New(context.TODO(), "TestListWatchReplayConcurrent", conn)
+	assert.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		err = l.Append(context.Background(), []byte(strconv.Itoa(i)))
+		assert.NoError(t, err)
+	}
+
+	// Values appended once the watch stream is open and before the values are read are reflected in the replay
+	// and also delivered by the stream
+	once := &sync.Once{}
+	l.(*list).client = &replayHookClient{
+		ListServiceClient: l.(*list).client,
+		hook: func() {
+			once.Do(func() {
+				for i := 10; i < 20; i++ {
+					assert.NoError(t, l.Append(context.Background(), []byte(strconv.Itoa(i))))
+				}
+			})
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := make(chan Event)
+	err = l.Watch(ctx, ch, WithReplay())
+	assert.NoError(t, err)
+
+	for i := 0; i < 20; i++ {
+		event := <-ch
+		assert.Equal(t, EventReplay, event.Type)
+		assert.Equal(t, i, event.Index)
+		assert.Equal(t, strconv.Itoa(i), string(event.Value))
+	}
+	event := <-ch
+	assert.Equal(t, EventSynced, event.Type)
+	for i := 10; i < 20; i++ {
+		event := <-ch
+		assert.Equal(t, EventAdd, event.Type)
+		assert.Equal(t, i, event.Index)
+		assert.Equal(t, strconv.Itoa(i), string(event.Value))
+	}
+
+	select {
+	case event := <-ch:
+		assert.Fail(t, "unexpected event", event)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	assert.NoError(t, test.Stop())
+}
+
+func TestListWatchReplayDuplicates(t *testing.T) {
+	logging.SetLevel(logging.DebugLevel)
+
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestListWatchReplayDuplicates",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	l, err := New(context.TODO(), "TestListWatchReplayDuplicates", conn)
+	assert.NoError(t, err)
+
+	assert.NoError(t, l.Append(context.Background(), []byte("a")))
+	assert.NoError(t, l.Append(context.Background(), []byte("a")))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := make(chan Event)
+	err = l.Watch(ctx, ch, WithReplay())
+	assert.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		event := <-ch
+		assert.Equal(t, EventReplay, event.Type)
+		assert.Equal(t, i, event.Index)
+		assert.Equal(t, "a", string(event.Value))
+	}
+	event := <-ch
+	assert.Equal(t, EventSynced, event.Type)
+
+	// Changes that leave an equal value at their index are delivered
+	assert.NoError(t, l.Insert(context.Background(), 0, []byte("a")))
+	event = <-ch
+	assert.Equal(t, EventAdd, event.Type)
+	assert.Equal(t, 0, event.Index)
+	assert.Equal(t, "a", string(event.Value))
+
+	_, err = l.Remove(context.Background(), 1)
+	assert.NoError(t, err)
+	event = <-ch
+	assert.Equal(t, EventRemove, event.Type)
+	assert.Equal(t, 1, event.Index)
+	assert.Equal(t, "a", string(event.Value))
+
+	assert.NoError(t, test.Stop())
+}
@@ -35,6 +35,9 @@ type WatchOption interface {
 }
 
 // WithReplay returns a Watch option to replay entries
+// The values in the list are sent as EventReplay events followed by an EventSynced event. List events carry no
+// revision, so changes made while the values are read may be both reflected in the replay and delivered after
+// it. Consumers must tolerate such duplicates.
 func WithReplay() WatchOption {
 	return replayOption{}
 }
@@ -48,3 +51,48 @@ func (o replayOption) beforeWatch(request *api.EventsRequest) {
 func (o replayOption) afterWatch(response *api.EventsResponse) {
 
 }
+
+// WithBuffer returns a watch option that buffers events between the watch stream and the channel
+// See primitive.WatchBuffer. The OverflowCoalesce policy is not supported by lists.
+func WithBuffer(size int, policy primitive.OverflowPolicy) *BufferOption {
+	return &BufferOption{
+		WatchBuffer: primitive.NewWatchBuffer(size, policy),
+	}
+}
+
+// BufferOption is a watch option that configures the buffering of watch events
+type BufferOption struct {
+	*primitive.WatchBuffer
+}
+
+func (o *BufferOption) beforeWatch(request *api.EventsRequest) {
+}
+
+func (o *BufferOption) afterWatch(response *api.EventsResponse) {
+}
+
+// WithAutoReconnect returns a watch option that re-establishes the watch stream when it fails
+// The stream is re-opened with backoff until the watch context is done. Because changes to the list that were
+// missed while the stream was down cannot be determined, an EventResync event is sent once the stream is
+// re-opened, followed by the replay of all the values in the list.
+func WithAutoReconnect() WatchOption {
+	return reconnectOption{}
+}
+
+type reconnectOption struct{}
+
+func (o reconnectOption) beforeWatch(request *api.EventsRequest) {
+}
+
+func (o reconnectOption) afterWatch(response *api.EventsResponse) {
+}
+
+// isAutoReconnect returns a bool indicating whether the given watch options enable auto-reconnect
+func isAutoReconnect(opts []WatchOption) bool {
+	for i := range opts {
+		if _, ok := opts[i].(reconnectOption); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package list
+
+import (
+	"context"
+	api "github.com/atomix/atomix-api/go/atomix/primitive/list"
+	"github.com/atomix/atomix-go-client/pkg/atomix/primitive"
+	"io"
+)
+
+// replay sends the values in the list as EventReplay events in index order
+// The values are read once the watch stream is open, so changes made while the values are being read may be
+// both reflected in the replay and delivered by the stream. List events carry no revision with which to tell
+// whether they're reflected in the replay, so such changes are delivered as is.
+func (l *list) replay(ctx context.Context, send func(Event) bool) error {
+	iterator, err := l.Iterate(ctx)
+	if err != nil {
+		return err
+	}
+	defer iterator.Close()
+	for index := 0; ; index++ {
+		value, err := iterator.Next(ctx)
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if !send(Event{Type: EventReplay, Index: index, Value: value}) {
+			return ctx.Err()
+		}
+	}
+}
+
+// resync re-establishes a failed watch stream
+// Because list events cannot be matched to the state of the list, changes missed while the stream was down
+// cannot be determined. Instead, the values in the list are replayed once the re-opened stream has delivered
+// its first response so the consumer can rebuild its view.
+// The new stream and a function to cancel it are returned.
+func (l *list) resync(ctx context.Context, request *api.EventsRequest) (api.ListService_EventsClient, context.CancelFunc, error) {
+	var stream api.ListService_EventsClient
+	var cancel context.CancelFunc
+	err := primitive.RetryWithBackoff(ctx, func() error {
+		var streamCtx context.Context
+		streamCtx, cancel = context.WithCancel(ctx)
+		var err error
+		stream, err = l.client.Events(streamCtx, request)
+		if err != nil {
+			cancel()
+			log.Warnf("Failed to reconnect watch: %v", err)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return stream, cancel, nil
+}
@@ -0,0 +1,264 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package _map //nolint:golint
+
+import (
+	"context"
+	"fmt"
+	"github.com/atomix/atomix-go-framework/pkg/atomix/errors"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultBatchConcurrency is the default number of concurrent requests issued by batch operations
+	defaultBatchConcurrency = 16
+	// batchRollbackTimeout is the maximum time allowed to roll back a failed batch
+	batchRollbackTimeout = 30 * time.Second
+)
+
+// BatchResult is the result of a batch operation for a single key
+type BatchResult struct {
+	// Key is the key to which the result applies
+	Key string
+
+	// Entry is the entry returned for the key, if any
+	Entry *Entry
+
+	// Error is the error returned for the key, if any
+	Error error
+
+	// RollbackError is the error returned when rolling back the write to the key, if any
+	// If set, the write to the key remains applied.
+	RollbackError error
+}
+
+// batchWrite is a single write in an atomically applied set of writes
+type batchWrite struct {
+	key    string
+	value  []byte
+	remove bool
+	// prev is the entry expected to be replaced by the write, or nil if the key must not exist
+	prev *Entry
+}
+
+// forEach calls the given function for each index in [0, n) using at most concurrency goroutines
+func forEach(n int, concurrency int, f func(i int)) {
+	if concurrency <= 0 {
+		concurrency = defaultBatchConcurrency
+	}
+	sem := make(chan struct{}, concurrency)
+	wg := &sync.WaitGroup{}
+	for i := 0; i < n; i++ {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			f(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+func putAll(ctx context.Context, m Map, entries []Entry, opts ...BatchOption) ([]BatchResult, error) {
+	options := newBatchOptions(opts...)
+	if options.atomic {
+		writes := make([]batchWrite, len(entries))
+		for i, entry := range entries {
+			writes[i] = batchWrite{
+				key:   entry.Key,
+				value: entry.Value,
+			}
+		}
+		if err := readBatchWrites(ctx, m, writes, options); err != nil {
+			return nil, err
+		}
+		for i, entry := range entries {
+			if entry.Revision != 0 && (writes[i].prev == nil || writes[i].prev.Revision != entry.Revision) {
+				return nil, errors.NewConflict("precondition failed for key '%s'", entry.Key)
+			}
+		}
+		return applyBatchWrites(ctx, m, writes, options)
+	}
+
+	results := make([]BatchResult, len(entries))
+	forEach(len(entries), options.concurrency, func(i int) {
+		entry := entries[i]
+		var putOpts []PutOption
+		if entry.Revision != 0 {
+			putOpts = append(putOpts, IfMatch(entry))
+		}
+		result, err := m.Put(ctx, entry.Key, entry.Value, putOpts...)
+		results[i] = BatchResult{
+			Key:   entry.Key,
+			Entry: result,
+			Error: err,
+		}
+	})
+	return results, ctx.Err()
+}
+
+func getAll(ctx context.Context, m Map, keys []string, opts ...BatchOption) ([]BatchResult, error) {
+	options := newBatchOptions(opts...)
+	results := make([]BatchResult, len(keys))
+	forEach(len(keys), options.concurrency, func(i int) {
+		entry, err := m.Get(ctx, keys[i])
+		results[i] = BatchResult{
+			Key:   keys[i],
+			Entry: entry,
+			Error: err,
+		}
+	})
+	return results, ctx.Err()
+}
+
+func removeAll(ctx context.Context, m Map, keys []string, opts ...BatchOption) ([]BatchResult, error) {
+	options := newBatchOptions(opts...)
+	if options.atomic {
+		writes := make([]batchWrite, len(keys))
+		for i, key := range keys {
+			writes[i] = batchWrite{
+				key:    key,
+				remove: true,
+			}
+		}
+		if err := readBatchWrites(ctx, m, writes, options); err != nil {
+			return nil, err
+		}
+		for _, write := range writes {
+			if write.prev == nil {
+				return nil, errors.NewNotFound("key '%s' not found", write.key)
+			}
+		}
+		return applyBatchWrites(ctx, m, writes, options)
+	}
+
+	results := make([]BatchResult, len(keys))
+	forEach(len(keys), options.concurrency, func(i int) {
+		entry, err := m.Remove(ctx, keys[i])
+		results[i] = BatchResult{
+			Key:   keys[i],
+			Entry: entry,
+			Error: err,
+		}
+	})
+	return results, ctx.Err()
+}
+
+// readBatchWrites reads the current entry for each write to be used as the write's precondition
+func readBatchWrites(ctx context.Context, m Map, writes []batchWrite, options batchOptions) error {
+	errs := make([]error, len(writes))
+	forEach(len(writes), options.concurrency, func(i int) {
+		entry, err := m.Get(ctx, writes[i].key)
+		if err != nil {
+			if !errors.IsNotFound(err) {
+				errs[i] = err
+			}
+			return
+		}
+		writes[i].prev = entry
+	})
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+// applyBatchWrites applies the given writes conditional on the entries they replace
+// If any write fails, the writes that were successfully applied are rolled back and the error for the
+// first failed key is returned. Rollbacks are themselves conditional on the written entries, so a key
+// that is modified concurrently with the rollback retains the concurrent modification. Rollbacks are
+// applied even if the context is cancelled, and the keys that could not be rolled back are marked in
+// their results and named in the returned error. Removed entries are restored with their remaining TTL.
+func applyBatchWrites(ctx context.Context, m Map, writes []batchWrite, options batchOptions) ([]BatchResult, error) {
+	results := make([]BatchResult, len(writes))
+	forEach(len(writes), options.concurrency, func(i int) {
+		write := writes[i]
+		var entry *Entry
+		var err error
+		if write.remove {
+			entry, err = m.Remove(ctx, write.key, IfMatch(write.prev))
+		} else if write.prev != nil {
+			entry, err = m.Put(ctx, write.key, write.value, IfMatch(write.prev))
+		} else {
+			entry, err = m.Put(ctx, write.key, write.value, IfNotSet())
+		}
+		results[i] = BatchResult{
+			Key:   write.key,
+			Entry: entry,
+			Error: err,
+		}
+	})
+
+	var failed *BatchResult
+	for i := range results {
+		if results[i].Error != nil {
+			failed = &results[i]
+			break
+		}
+	}
+	if failed == nil {
+		return results, nil
+	}
+
+	// Roll back under a separate context so a cancelled batch is not left partially applied
+	rollbackCtx, cancel := context.WithTimeout(context.Background(), batchRollbackTimeout)
+	defer cancel()
+	forEach(len(writes), options.concurrency, func(i int) {
+		if results[i].Error != nil {
+			return
+		}
+		write := writes[i]
+		var restoreOpts []PutOption
+		if write.prev != nil && write.prev.TTL > 0 {
+			restoreOpts = append(restoreOpts, WithTTL(write.prev.TTL))
+		}
+		var err error
+		if write.remove {
+			_, err = m.Put(rollbackCtx, write.key, write.prev.Value, append(restoreOpts, IfNotSet())...)
+		} else if write.prev != nil {
+			_, err = m.Put(rollbackCtx, write.key, write.prev.Value, append(restoreOpts, IfMatch(results[i].Entry))...)
+		} else {
+			_, err = m.Remove(rollbackCtx, write.key, IfMatch(results[i].Entry))
+		}
+		if err != nil {
+			results[i].RollbackError = err
+		}
+	})
+
+	var unrolled []string
+	for _, result := range results {
+		if result.RollbackError != nil {
+			unrolled = append(unrolled, result.Key)
+		}
+	}
+
+	// Writes are conditional on the entries read, so a key that was added or removed concurrently is also a conflict
+	var err error
+	if errors.IsConflict(failed.Error) || errors.IsNotFound(failed.Error) || errors.IsAlreadyExists(failed.Error) {
+		err = errors.NewConflict("precondition failed for key '%s'", failed.Key)
+	} else {
+		err = failed.Error
+	}
+	if len(unrolled) > 0 {
+		return results, errors.New(errors.TypeOf(err), "%s; failed to roll back keys %s", err.Error(), fmt.Sprint(unrolled))
+	}
+	return results, err
+}
@@ -0,0 +1,386 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package _map //nolint:golint
+
+import (
+	"container/list"
+	"context"
+	"github.com/atomix/atomix-go-client/pkg/atomix/primitive"
+	"github.com/atomix/atomix-go-framework/pkg/atomix/errors"
+	"sync"
+)
+
+const defaultCacheSize = 1000
+
+// CachedMap is a Map that serves reads from a local cache
+type CachedMap interface {
+	Map
+
+	// Stats returns the cache statistics
+	Stats() CacheStats
+}
+
+// CacheStats is a snapshot of the statistics of a map cache
+type CacheStats struct {
+	// Hits is the number of reads served by the cache
+	Hits uint64
+
+	// Misses is the number of reads served by the map
+	Misses uint64
+
+	// Size is the number of keys in the cache
+	Size int
+}
+
+// NewCachedMap creates a new CachedMap that caches the entries read from the given map
+// The cache holds a bounded number of recently read keys, including keys that were not found. Cached keys
+// are updated from a background watch on the map. While the watch is not connected, the cache is cleared and
+// reads are served by the map. Writes made through the cached map, including transactions, invalidate the written
+// keys, and all other changes are reflected in the cache once their events are received by the watch.
+// Closing the cached map stops the watch and closes the underlying map.
+func NewCachedMap(m Map, opts ...CacheOption) (CachedMap, error) {
+	options := cacheOptions{
+		size: defaultCacheSize,
+	}
+	for i := range opts {
+		opts[i].applyCache(&options)
+	}
+	if options.size <= 0 {
+		return nil, errors.NewInvalid("cache size must be positive")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cache := &cachedMap{
+		Map:     m,
+		size:    options.size,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+		loading: make(map[string]*cacheLoad),
+		cancel:  cancel,
+	}
+	go cache.watch(ctx)
+	return cache, nil
+}
+
+// cacheEntry is a cached key
+// The entry is nil if the key was not found.
+type cacheEntry struct {
+	key   string
+	entry *Entry
+}
+
+// cacheLoad tracks the reads of a key from the map
+// If the key is changed while it's being read, the value read may be stale and is not cached.
+type cacheLoad struct {
+	refs  int
+	stale bool
+}
+
+// cachedMap is a Map that serves reads from a local LRU cache
+type cachedMap struct {
+	Map
+	size      int
+	mu        sync.Mutex
+	entries   map[string]*list.Element
+	lru       *list.List
+	loading   map[string]*cacheLoad
+	connected bool
+	epoch     uint64
+	hits      uint64
+	misses    uint64
+	cancel    context.CancelFunc
+}
+
+// watch watches the map for changes to apply to the cache, re-opening the watch until the context is done
+func (m *cachedMap) watch(ctx context.Context) {
+	for {
+		ch := make(chan Event)
+		err := primitive.RetryWithBackoff(ctx, func() error {
+			err := m.Map.Watch(ctx, ch)
+			if err != nil {
+				log.Warnf("Failed to open cache watch: %v", err)
+			}
+			return err
+		})
+		if err != nil {
+			return
+		}
+
+		m.setConnected(true)
+		for event := range ch {
+			m.apply(event)
+		}
+		m.setConnected(false)
+
+		if ctx.Err() != nil {
+			return
+		}
+		log.Warnf("Cache watch closed; reading from the map until it's re-opened")
+	}
+}
+
+// setConnected sets whether the watch is connected
+// The changes made while the watch is not connected are unknown, so the cache is cleared.
+func (m *cachedMap) setConnected(connected bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.connected = connected
+	m.epoch++
+	m.clear()
+}
+
+// apply applies the given event to the cache
+func (m *cachedMap) apply(event Event) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.invalidateLoad(event.Entry.Key)
+	elem, ok := m.entries[event.Entry.Key]
+	if !ok {
+		return
+	}
+	// Events may be received after the key is read from the map. Events older than the cached entry are ignored.
+	cached := elem.Value.(*cacheEntry)
+	if cached.entry != nil && event.Entry.Revision < cached.entry.Revision {
+		return
+	}
+	switch event.Type {
+	case EventInsert, EventUpdate:
+		cached.entry = copyEntry(&event.Entry)
+	case EventRemove, EventExpire:
+		cached.entry = nil
+	}
+}
+
+// add adds the given entry for the given key to the cache, evicting the least recently used key if necessary
+func (m *cachedMap) add(key string, entry *Entry) {
+	if elem, ok := m.entries[key]; ok {
+		elem.Value.(*cacheEntry).entry = entry
+		m.lru.MoveToFront(elem)
+		return
+	}
+	m.entries[key] = m.lru.PushFront(&cacheEntry{key: key, entry: entry})
+	if m.lru.Len() > m.size {
+		elem := m.lru.Back()
+		m.lru.Remove(elem)
+		delete(m.entries, elem.Value.(*cacheEntry).key)
+	}
+}
+
+// invalidate removes the given keys from the cache
+func (m *cachedMap) invalidate(keys ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, key := range keys {
+		m.invalidateLoad(key)
+		if elem, ok := m.entries[key]; ok {
+			m.lru.Remove(elem)
+			delete(m.entries, key)
+		}
+	}
+}
+
+// invalidateLoad marks in-progress reads of the given key as stale
+func (m *cachedMap) invalidateLoad(key string) {
+	if load, ok := m.loading[key]; ok {
+		load.stale = true
+	}
+}
+
+// clear removes all keys from the cache
+func (m *cachedMap) clear() {
+	for _, load := range m.loading {
+		load.stale = true
+	}
+	m.entries = make(map[string]*list.Element)
+	m.lru.Init()
+}
+
+// copyEntry returns a copy of the given entry that can be safely modified by the caller
+func copyEntry(entry *Entry) *Entry {
+	result := *entry
+	result.Value = append([]byte(nil), entry.Value...)
+	return &result
+}
+
+func (m *cachedMap) Get(ctx context.Context, key string, opts ...GetOption) (*Entry, error) {
+	if len(opts) > 0 {
+		return m.Map.Get(ctx, key, opts...)
+	}
+
+	m.mu.Lock()
+	if !m.connected {
+		m.misses++
+		m.mu.Unlock()
+		return m.Map.Get(ctx, key)
+	}
+	if elem, ok := m.entries[key]; ok {
+		m.hits++
+		m.lru.MoveToFront(elem)
+		entry := elem.Value.(*cacheEntry).entry
+		m.mu.Unlock()
+		if entry == nil {
+			return nil, errors.NewNotFound("key '%s' not found", key)
+		}
+		return copyEntry(entry), nil
+	}
+	m.misses++
+	load, ok := m.loading[key]
+	if !ok {
+		load = &cacheLoad{}
+		m.loading[key] = load
+	}
+	load.refs++
+	epoch := m.epoch
+	m.mu.Unlock()
+
+	entry, err := m.Map.Get(ctx, key)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	load.refs--
+	if load.refs == 0 {
+		delete(m.loading, key)
+	}
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, err
+	}
+	if !load.stale && m.epoch == epoch {
+		if entry != nil {
+			m.add(key, copyEntry(entry))
+		} else {
+			m.add(key, nil)
+		}
+	}
+	return entry, err
+}
+
+func (m *cachedMap) Put(ctx context.Context, key string, value []byte, opts ...PutOption) (*Entry, error) {
+	defer m.invalidate(key)
+	return m.Map.Put(ctx, key, value, opts...)
+}
+
+func (m *cachedMap) Remove(ctx context.Context, key string, opts ...RemoveOption) (*Entry, error) {
+	defer m.invalidate(key)
+	return m.Map.Remove(ctx, key, opts...)
+}
+
+func (m *cachedMap) PutAll(ctx context.Context, entries []Entry, opts ...BatchOption) ([]BatchResult, error) {
+	keys := make([]string, len(entries))
+	for i, entry := range entries {
+		keys[i] = entry.Key
+	}
+	defer m.invalidate(keys...)
+	return m.Map.PutAll(ctx, entries, opts...)
+}
+
+func (m *cachedMap) RemoveAll(ctx context.Context, keys []string, opts ...BatchOption) ([]BatchResult, error) {
+	defer m.invalidate(keys...)
+	return m.Map.RemoveAll(ctx, keys, opts...)
+}
+
+func (m *cachedMap) Compute(ctx context.Context, key string, f ComputeFunc, opts ...ComputeOption) (*Entry, error) {
+	defer m.invalidate(key)
+	return m.Map.Compute(ctx, key, f, opts...)
+}
+
+func (m *cachedMap) ComputeIfAbsent(ctx context.Context, key string, f func(key string) ([]byte, error), opts ...ComputeOption) (*Entry, error) {
+	defer m.invalidate(key)
+	return m.Map.ComputeIfAbsent(ctx, key, f, opts...)
+}
+
+func (m *cachedMap) ComputeIfPresent(ctx context.Context, key string, f ComputeFunc, opts ...ComputeOption) (*Entry, error) {
+	defer m.invalidate(key)
+	return m.Map.ComputeIfPresent(ctx, key, f, opts...)
+}
+
+func (m *cachedMap) Merge(ctx context.Context, key string, value []byte, f MergeFunc, opts ...ComputeOption) (*Entry, error) {
+	defer m.invalidate(key)
+	return m.Map.Merge(ctx, key, value, f, opts...)
+}
+
+func (m *cachedMap) GetAndPut(ctx context.Context, key string, value []byte, opts ...ComputeOption) (*Entry, error) {
+	defer m.invalidate(key)
+	return m.Map.GetAndPut(ctx, key, value, opts...)
+}
+
+func (m *cachedMap) PutIfAbsent(ctx context.Context, key string, value []byte, opts ...PutOption) (*Entry, bool, error) {
+	defer m.invalidate(key)
+	return m.Map.PutIfAbsent(ctx, key, value, opts...)
+}
+
+func (m *cachedMap) Txn() Transaction {
+	return &cachedTransaction{
+		Transaction: m.Map.Txn(),
+		m:           m,
+	}
+}
+
+// cachedTransaction is a Transaction that invalidates the keys it writes once it's committed
+type cachedTransaction struct {
+	Transaction
+	m    *cachedMap
+	keys []string
+}
+
+func (t *cachedTransaction) If(key string, version Version) Transaction {
+	t.Transaction.If(key, version)
+	return t
+}
+
+func (t *cachedTransaction) Put(key string, value []byte) Transaction {
+	t.keys = append(t.keys, key)
+	t.Transaction.Put(key, value)
+	return t
+}
+
+func (t *cachedTransaction) Remove(key string) Transaction {
+	t.keys = append(t.keys, key)
+	t.Transaction.Remove(key)
+	return t
+}
+
+func (t *cachedTransaction) Commit(ctx context.Context, opts ...BatchOption) ([]BatchResult, error) {
+	defer t.m.invalidate(t.keys...)
+	return t.Transaction.Commit(ctx, opts...)
+}
+
+func (m *cachedMap) Clear(ctx context.Context) error {
+	defer func() {
+		m.mu.Lock()
+		m.clear()
+		m.mu.Unlock()
+	}()
+	return m.Map.Clear(ctx)
+}
+
+func (m *cachedMap) Stats() CacheStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return CacheStats{
+		Hits:   m.hits,
+		Misses: m.misses,
+		Size:   m.lru.Len(),
+	}
+}
+
+func (m *cachedMap) Close(ctx context.Context) error {
+	m.cancel()
+	return m.Map.Close(ctx)
+}
+
+func (m *cachedMap) Delete(ctx context.Context) error {
+	m.cancel()
+	return m.Map.Delete(ctx)
+}
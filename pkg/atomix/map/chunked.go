@@ -0,0 +1,557 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package _map //nolint:golint
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"github.com/atomix/atomix-go-client/pkg/atomix/primitive"
+	"github.com/atomix/atomix-go-framework/pkg/atomix/errors"
+	"github.com/google/uuid"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// chunkKeyPrefix is the prefix of keys in which value chunks are stored
+	chunkKeyPrefix = "__chunk__/"
+	// chunkManifestVersion is the version of the manifest encoding
+	chunkManifestVersion byte = 1
+	// maxChunkReadAttempts is the number of times a read is retried when a chunked value is concurrently replaced
+	maxChunkReadAttempts = 5
+)
+
+// chunkManifestMagic prefixes every manifest value
+var chunkManifestMagic = []byte{0xA7, 'C', 'H', 'K'}
+
+// chunkManifest describes a value that has been split into chunks
+type chunkManifest struct {
+	writeID  string
+	chunks   uint32
+	size     uint64
+	checksum [sha256.Size]byte
+}
+
+// chunkKey returns the key of the chunk at the given index
+func (m chunkManifest) chunkKey(index uint32) string {
+	return getChunkKey(m.writeID, index)
+}
+
+func getChunkKey(writeID string, index uint32) string {
+	return fmt.Sprintf("%s%s/%d", chunkKeyPrefix, writeID, index)
+}
+
+// isChunkKey returns a bool indicating whether the given key is used to store a value chunk
+func isChunkKey(key string) bool {
+	return strings.HasPrefix(key, chunkKeyPrefix)
+}
+
+// parseChunkKey returns the write ID and write time encoded in the given chunk key
+func parseChunkKey(key string) (string, time.Time, bool) {
+	parts := strings.Split(strings.TrimPrefix(key, chunkKeyPrefix), "/")
+	if len(parts) != 2 {
+		return "", time.Time{}, false
+	}
+	writeID := parts[0]
+	i := strings.Index(writeID, "-")
+	if i < 0 {
+		return "", time.Time{}, false
+	}
+	nanos, err := strconv.ParseInt(writeID[:i], 16, 64)
+	if err != nil {
+		return "", time.Time{}, false
+	}
+	return writeID, time.Unix(0, nanos), true
+}
+
+// newWriteID returns a unique identifier for a chunked write
+// The identifier is prefixed with the write time to allow orphaned chunks to be aged out.
+func newWriteID() string {
+	return fmt.Sprintf("%016x-%s", time.Now().UnixNano(), uuid.New().String())
+}
+
+func encodeChunkManifest(manifest chunkManifest) []byte {
+	buf := &bytes.Buffer{}
+	buf.Write(chunkManifestMagic)
+	buf.WriteByte(chunkManifestVersion)
+	buf.WriteByte(byte(len(manifest.writeID)))
+	buf.WriteString(manifest.writeID)
+	_ = binary.Write(buf, binary.BigEndian, manifest.chunks)
+	_ = binary.Write(buf, binary.BigEndian, manifest.size)
+	buf.Write(manifest.checksum[:])
+	return buf.Bytes()
+}
+
+// decodeChunkManifest decodes the manifest stored in the given value, returning false if the value is not a manifest
+func decodeChunkManifest(value []byte) (chunkManifest, bool) {
+	manifest := chunkManifest{}
+	if !bytes.HasPrefix(value, chunkManifestMagic) {
+		return manifest, false
+	}
+	value = value[len(chunkManifestMagic):]
+	if len(value) < 2 || value[0] != chunkManifestVersion {
+		return manifest, false
+	}
+	idLen := int(value[1])
+	value = value[2:]
+	if len(value) != idLen+4+8+sha256.Size {
+		return manifest, false
+	}
+	manifest.writeID = string(value[:idLen])
+	value = value[idLen:]
+	manifest.chunks = binary.BigEndian.Uint32(value[:4])
+	manifest.size = binary.BigEndian.Uint64(value[4:12])
+	copy(manifest.checksum[:], value[12:])
+	return manifest, true
+}
+
+// WithChunkSize returns a map option that splits values larger than the given size into chunks
+// Chunked values are stored across multiple hidden entries and a manifest entry under the value's key. Chunks
+// are reassembled transparently by Get, Entries and Watch. The chunk size should be chosen to keep chunks
+// well below the gRPC message size limit.
+func WithChunkSize(size int) Option {
+	return &chunkSizeOption{size: size}
+}
+
+type chunkSizeOption struct {
+	primitive.EmptyOption
+	size int
+}
+
+func (o *chunkSizeOption) applyNewMap(options *newMapOptions) {
+	options.chunkSize = o.size
+}
+
+// CleanupChunks removes chunks that are not referenced by any entry in the given map
+// Chunks are orphaned when a client fails while writing a chunked value. Only chunks written more than
+// the given age ago are removed to avoid removing the chunks of writes that are still in progress.
+// The map must have been created with WithChunkSize. If the entries cannot be read, no chunk is removed and
+// the error is returned.
+func CleanupChunks(ctx context.Context, m Map, age time.Duration) (int, error) {
+	chunked, ok := m.(*chunkedMap)
+	if !ok {
+		return 0, errors.NewNotSupported("map '%s' is not chunked", m.Name())
+	}
+	return chunked.cleanup(ctx, age)
+}
+
+// chunkedMap is a Map that splits large values across multiple entries
+type chunkedMap struct {
+	*_map
+	chunkSize int
+}
+
+func (m *chunkedMap) Put(ctx context.Context, key string, value []byte, opts ...PutOption) (*Entry, error) {
+	if isChunkKey(key) {
+		return nil, errors.NewInvalid("key '%s' is reserved", key)
+	}
+
+	// Conditions are checked against the reassembled value
+	opts, err := resolvePutConditions(ctx, key, m.Get, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Small values are stored directly under the key.
+	stored := value
+	var manifest *chunkManifest
+	if len(value) > m.chunkSize {
+		// Chunks expire with the manifest that references them
+		var chunkOpts []PutOption
+		for _, opt := range opts {
+			if ttl, ok := opt.(*TTLOption); ok {
+				chunkOpts = append(chunkOpts, ttl)
+			}
+		}
+		written, err := m.writeChunks(ctx, value, chunkOpts...)
+		if err != nil {
+			return nil, err
+		}
+		manifest = &written
+		stored = encodeChunkManifest(written)
+	}
+
+	entry, err := m.replace(ctx, key, stored, opts...)
+	if err != nil {
+		if manifest != nil {
+			m.removeChunks(ctx, *manifest)
+		}
+		return nil, err
+	}
+	entry.Value = value
+	return entry, nil
+}
+
+// replace writes the given value to the key, removing the chunks of the value it replaces
+// The write is always conditioned on the replaced value, ensuring the chunks of concurrently written values
+// are not leaked. If the caller provided no preconditions of its own, conflicting writes are retried.
+func (m *chunkedMap) replace(ctx context.Context, key string, value []byte, opts ...PutOption) (*Entry, error) {
+	conditional := false
+	for _, opt := range opts {
+		switch opt.(type) {
+		case MatchOption, *MatchOption, NotSetOption, *NotSetOption:
+			conditional = true
+		}
+	}
+
+	for {
+		prev, err := m._map.Get(ctx, key)
+		if err != nil && !errors.IsNotFound(err) {
+			return nil, err
+		}
+
+		putOpts := make([]PutOption, 0, len(opts)+1)
+		putOpts = append(putOpts, opts...)
+		if prev != nil {
+			putOpts = append(putOpts, IfMatch(prev))
+		} else {
+			putOpts = append(putOpts, IfNotSet())
+		}
+
+		entry, err := m._map.Put(ctx, key, value, putOpts...)
+		if err != nil {
+			if errors.IsConflict(err) && !conditional {
+				continue
+			}
+			return nil, err
+		}
+
+		if prev != nil && prev.Revision != entry.Revision {
+			if manifest, ok := decodeChunkManifest(prev.Value); ok {
+				m.removeChunks(ctx, manifest)
+			}
+		}
+		return entry, nil
+	}
+}
+
+// writeChunks splits the given value into chunks and writes them to the map
+func (m *chunkedMap) writeChunks(ctx context.Context, value []byte, opts ...PutOption) (chunkManifest, error) {
+	manifest := chunkManifest{
+		writeID:  newWriteID(),
+		size:     uint64(len(value)),
+		checksum: sha256.Sum256(value),
+	}
+	for offset := 0; offset < len(value); offset += m.chunkSize {
+		end := offset + m.chunkSize
+		if end > len(value) {
+			end = len(value)
+		}
+		if _, err := m._map.Put(ctx, manifest.chunkKey(manifest.chunks), value[offset:end], append(opts, IfNotSet())...); err != nil {
+			m.removeChunks(ctx, manifest)
+			return manifest, err
+		}
+		manifest.chunks++
+	}
+	return manifest, nil
+}
+
+// readChunks reads and reassembles the chunks of the given manifest
+func (m *chunkedMap) readChunks(ctx context.Context, manifest chunkManifest) ([]byte, error) {
+	value := make([]byte, 0, manifest.size)
+	for i := uint32(0); i < manifest.chunks; i++ {
+		chunk, err := m._map.Get(ctx, manifest.chunkKey(i))
+		if err != nil {
+			return nil, err
+		}
+		value = append(value, chunk.Value...)
+	}
+	if uint64(len(value)) != manifest.size || sha256.Sum256(value) != manifest.checksum {
+		return nil, errors.NewConflict("chunked value checksum mismatch")
+	}
+	return value, nil
+}
+
+// removeChunks removes the chunks of the given manifest
+// Failures are logged and the chunks are left to be removed by CleanupChunks.
+func (m *chunkedMap) removeChunks(ctx context.Context, manifest chunkManifest) {
+	for i := uint32(0); i < manifest.chunks; i++ {
+		if _, err := m._map.Remove(ctx, manifest.chunkKey(i)); err != nil && !errors.IsNotFound(err) {
+			log.Warnf("Failed to remove chunk %s: %v", manifest.chunkKey(i), err)
+		}
+	}
+}
+
+// resolve replaces the manifest value of the given entry with the reassembled chunks
+func (m *chunkedMap) resolve(ctx context.Context, entry *Entry) error {
+	manifest, ok := decodeChunkManifest(entry.Value)
+	if !ok {
+		return nil
+	}
+	value, err := m.readChunks(ctx, manifest)
+	if err != nil {
+		return err
+	}
+	entry.Value = value
+	return nil
+}
+
+func (m *chunkedMap) Get(ctx context.Context, key string, opts ...GetOption) (*Entry, error) {
+	var err error
+	for i := 0; i < maxChunkReadAttempts; i++ {
+		var entry *Entry
+		entry, err = m._map.Get(ctx, key, opts...)
+		if err != nil {
+			return nil, err
+		}
+		err = m.resolve(ctx, entry)
+		if err == nil {
+			return entry, nil
+		}
+		// If the chunks were not found, the value was replaced during the read. Retry the read.
+		if !errors.IsNotFound(err) && !errors.IsConflict(err) {
+			return nil, err
+		}
+	}
+	return nil, errors.NewConflict("failed to read chunked value for key '%s': %v", key, err)
+}
+
+func (m *chunkedMap) Remove(ctx context.Context, key string, opts ...RemoveOption) (*Entry, error) {
+	if isChunkKey(key) {
+		return nil, errors.NewInvalid("key '%s' is reserved", key)
+	}
+	// Conditions are checked against the reassembled value
+	opts, err := resolveRemoveConditions(ctx, key, m.Get, opts)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := m._map.Remove(ctx, key, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if manifest, ok := decodeChunkManifest(entry.Value); ok {
+		value, err := m.readChunks(ctx, manifest)
+		if err != nil {
+			log.Warnf("Failed to read removed value for key '%s': %v", key, err)
+			entry.Value = nil
+		} else {
+			entry.Value = value
+		}
+		m.removeChunks(ctx, manifest)
+	}
+	return entry, nil
+}
+
+func (m *chunkedMap) PutAll(ctx context.Context, entries []Entry, opts ...BatchOption) ([]BatchResult, error) {
+	return putAll(ctx, m, entries, opts...)
+}
+
+func (m *chunkedMap) GetAll(ctx context.Context, keys []string, opts ...BatchOption) ([]BatchResult, error) {
+	return getAll(ctx, m, keys, opts...)
+}
+
+func (m *chunkedMap) RemoveAll(ctx context.Context, keys []string, opts ...BatchOption) ([]BatchResult, error) {
+	return removeAll(ctx, m, keys, opts...)
+}
+
+func (m *chunkedMap) Compute(ctx context.Context, key string, f ComputeFunc, opts ...ComputeOption) (*Entry, error) {
+	return compute(ctx, m, key, f, opts...)
+}
+
+func (m *chunkedMap) ComputeIfAbsent(ctx context.Context, key string, f func(key string) ([]byte, error), opts ...ComputeOption) (*Entry, error) {
+	return computeIfAbsent(ctx, m, key, f, opts...)
+}
+
+func (m *chunkedMap) ComputeIfPresent(ctx context.Context, key string, f ComputeFunc, opts ...ComputeOption) (*Entry, error) {
+	return computeIfPresent(ctx, m, key, f, opts...)
+}
+
+func (m *chunkedMap) Merge(ctx context.Context, key string, value []byte, f MergeFunc, opts ...ComputeOption) (*Entry, error) {
+	return merge(ctx, m, key, value, f, opts...)
+}
+
+func (m *chunkedMap) GetAndPut(ctx context.Context, key string, value []byte, opts ...ComputeOption) (*Entry, error) {
+	return getAndPut(ctx, m, key, value, opts...)
+}
+
+func (m *chunkedMap) PutIfAbsent(ctx context.Context, key string, value []byte, opts ...PutOption) (*Entry, bool, error) {
+	return putIfAbsent(ctx, m, key, value, opts...)
+}
+
+func (m *chunkedMap) Txn() Transaction {
+	return newTransaction(m)
+}
+
+func (m *chunkedMap) Len(ctx context.Context) (int, error) {
+	iterator, err := m._map.openEntries(ctx, newEntriesOptions(), false)
+	if err != nil {
+		return 0, err
+	}
+	defer iterator.Close()
+	size := 0
+	for {
+		entry, err := iterator.Next(ctx)
+		if err == io.EOF {
+			return size, nil
+		} else if err != nil {
+			return 0, err
+		}
+		if !isChunkKey(entry.Key) {
+			size++
+		}
+	}
+}
+
+func (m *chunkedMap) Iterate(ctx context.Context, opts ...EntriesOption) (EntryIterator, error) {
+	options := newEntriesOptions(opts...)
+	iterator, err := m._map.openEntries(ctx, options, true)
+	if err != nil {
+		return nil, err
+	}
+	return pageIterator(&entryIterator{
+		next: func(ctx context.Context) (Entry, error) {
+			for {
+				entry, err := iterator.Next(ctx)
+				if err != nil {
+					return Entry{}, err
+				}
+				if isChunkKey(entry.Key) {
+					continue
+				}
+				if err := m.resolve(ctx, &entry); err != nil {
+					// The value may have been replaced since the entries were read. Read the current value.
+					current, err := m.Get(ctx, entry.Key)
+					if err != nil {
+						if errors.IsNotFound(err) {
+							continue
+						}
+						return Entry{}, err
+					}
+					entry = *current
+				}
+				return entry, nil
+			}
+		},
+		close: iterator.Close,
+	}, options), nil
+}
+
+func (m *chunkedMap) Entries(ctx context.Context, ch chan<- Entry, opts ...EntriesOption) error {
+	iterator, err := m.Iterate(ctx, opts...)
+	if err != nil {
+		return err
+	}
+	go pushEntries(ctx, iterator, ch)
+	return nil
+}
+
+func (m *chunkedMap) Keys(ctx context.Context, ch chan<- string, opts ...EntriesOption) error {
+	options := newEntriesOptions(opts...)
+	iterator, err := m._map.openEntries(ctx, options, false)
+	if err != nil {
+		return err
+	}
+	go pushKeys(ctx, pageIterator(&entryIterator{
+		next: func(ctx context.Context) (Entry, error) {
+			for {
+				entry, err := iterator.Next(ctx)
+				if err != nil || !isChunkKey(entry.Key) {
+					return entry, err
+				}
+			}
+		},
+		close: iterator.Close,
+	}, options), ch)
+	return nil
+}
+
+func (m *chunkedMap) Watch(ctx context.Context, ch chan<- Event, opts ...WatchOption) error {
+	eventCh := make(chan Event)
+	if err := m._map.Watch(ctx, eventCh, opts...); err != nil {
+		return err
+	}
+	go func() {
+		defer close(ch)
+		for event := range eventCh {
+			if isChunkKey(event.Entry.Key) {
+				continue
+			}
+			event.Entry.Value = m.readEventValue(ctx, event.Entry)
+			if event.PrevEntry != nil {
+				prev := *event.PrevEntry
+				prev.Value = m.readEventValue(ctx, prev)
+				event.PrevEntry = &prev
+			}
+			select {
+			case ch <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// cleanup removes orphaned chunks older than the given age
+func (m *chunkedMap) cleanup(ctx context.Context, age time.Duration) (int, error) {
+	iterator, err := m._map.Iterate(ctx)
+	if err != nil {
+		return 0, err
+	}
+	referenced := make(map[string]bool)
+	chunks := make([]Entry, 0)
+	for {
+		entry, err := iterator.Next(ctx)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			iterator.Close()
+			return 0, err
+		}
+		if isChunkKey(entry.Key) {
+			chunks = append(chunks, entry)
+		} else if manifest, ok := decodeChunkManifest(entry.Value); ok {
+			referenced[manifest.writeID] = true
+		}
+	}
+	iterator.Close()
+
+	count := 0
+	cutoff := time.Now().Add(-age)
+	for _, chunk := range chunks {
+		writeID, written, ok := parseChunkKey(chunk.Key)
+		if !ok || referenced[writeID] || written.After(cutoff) {
+			continue
+		}
+		if _, err := m._map.Remove(ctx, chunk.Key, IfMatch(chunk)); err != nil {
+			if errors.IsNotFound(err) || errors.IsConflict(err) {
+				continue
+			}
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// readEventValue returns the value of the given event entry, reassembling the value if it's chunked
+func (m *chunkedMap) readEventValue(ctx context.Context, entry Entry) []byte {
+	manifest, ok := decodeChunkManifest(entry.Value)
+	if !ok {
+		return entry.Value
+	}
+	value, err := m.readChunks(ctx, manifest)
+	if err != nil {
+		// The chunks of removed or replaced values may already have been removed.
+		log.Debugf("Failed to read chunked value for key '%s': %v", entry.Key, err)
+		return nil
+	}
+	return value
+}
@@ -0,0 +1,189 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package _map //nolint:golint
+
+import (
+	"context"
+	"github.com/atomix/atomix-go-framework/pkg/atomix/errors"
+	"math/rand"
+	"time"
+)
+
+const (
+	// defaultInitialBackoff is the default delay before the first retry of a conflicting update
+	defaultInitialBackoff = 10 * time.Millisecond
+	// defaultMaxBackoff is the default maximum delay between retries of a conflicting update
+	defaultMaxBackoff = time.Second
+)
+
+// ComputeFunc computes a new value from the current entry for a key
+// The entry is nil if the key is not present in the map. If the function returns a nil value, the key
+// is removed from the map.
+type ComputeFunc func(entry *Entry) ([]byte, error)
+
+// MergeFunc merges a new value into the current value of a key
+// If the function returns a nil value, the key is removed from the map.
+type MergeFunc func(current []byte, value []byte) ([]byte, error)
+
+// updateFunc computes the update to a key from its current entry
+// If write is false, the current entry is returned without modifying the map.
+type updateFunc func(entry *Entry) (value []byte, write bool, err error)
+
+func compute(ctx context.Context, m Map, key string, f ComputeFunc, opts ...ComputeOption) (*Entry, error) {
+	return update(ctx, m, key, func(entry *Entry) ([]byte, bool, error) {
+		value, err := f(entry)
+		if err != nil {
+			return nil, false, err
+		}
+		return value, true, nil
+	}, opts...)
+}
+
+func computeIfAbsent(ctx context.Context, m Map, key string, f func(key string) ([]byte, error), opts ...ComputeOption) (*Entry, error) {
+	return update(ctx, m, key, func(entry *Entry) ([]byte, bool, error) {
+		if entry != nil {
+			return nil, false, nil
+		}
+		value, err := f(key)
+		if err != nil {
+			return nil, false, err
+		}
+		return value, value != nil, nil
+	}, opts...)
+}
+
+func computeIfPresent(ctx context.Context, m Map, key string, f ComputeFunc, opts ...ComputeOption) (*Entry, error) {
+	return update(ctx, m, key, func(entry *Entry) ([]byte, bool, error) {
+		if entry == nil {
+			return nil, false, errors.NewNotFound("key '%s' not found", key)
+		}
+		value, err := f(entry)
+		if err != nil {
+			return nil, false, err
+		}
+		return value, true, nil
+	}, opts...)
+}
+
+func merge(ctx context.Context, m Map, key string, value []byte, f MergeFunc, opts ...ComputeOption) (*Entry, error) {
+	return update(ctx, m, key, func(entry *Entry) ([]byte, bool, error) {
+		if entry == nil {
+			return value, true, nil
+		}
+		merged, err := f(entry.Value, value)
+		if err != nil {
+			return nil, false, err
+		}
+		return merged, true, nil
+	}, opts...)
+}
+
+func getAndPut(ctx context.Context, m Map, key string, value []byte, opts ...ComputeOption) (*Entry, error) {
+	// A nil value would remove the key
+	if value == nil {
+		value = []byte{}
+	}
+	var prev *Entry
+	_, err := update(ctx, m, key, func(entry *Entry) ([]byte, bool, error) {
+		prev = entry
+		return value, true, nil
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return prev, nil
+}
+
+func putIfAbsent(ctx context.Context, m Map, key string, value []byte, opts ...PutOption) (*Entry, bool, error) {
+	putOpts := make([]PutOption, 0, len(opts)+1)
+	putOpts = append(putOpts, opts...)
+	putOpts = append(putOpts, IfNotSet())
+	for {
+		entry, err := m.Put(ctx, key, value, putOpts...)
+		if err == nil {
+			return entry, true, nil
+		} else if !errors.IsConflict(err) && !errors.IsAlreadyExists(err) {
+			return nil, false, err
+		}
+
+		entry, err = m.Get(ctx, key)
+		if err == nil {
+			return entry, false, nil
+		} else if !errors.IsNotFound(err) {
+			return nil, false, err
+		}
+		// The existing entry was removed before it could be read. Retry the put.
+		log.Debugf("Retrying put of absent key '%s'", key)
+	}
+}
+
+// update applies the given update function to a key, retrying with backoff when the key is modified
+// concurrently with the update
+func update(ctx context.Context, m Map, key string, f updateFunc, opts ...ComputeOption) (*Entry, error) {
+	options := newComputeOptions(opts...)
+	backoff := options.initialBackoff
+	for attempt := 0; ; attempt++ {
+		entry, err := m.Get(ctx, key)
+		if err != nil {
+			if !errors.IsNotFound(err) {
+				return nil, err
+			}
+			entry = nil
+		}
+
+		value, write, err := f(entry)
+		if err != nil {
+			return nil, err
+		}
+		if !write {
+			return entry, nil
+		}
+
+		var result *Entry
+		if value == nil {
+			if entry == nil {
+				return nil, nil
+			}
+			_, err = m.Remove(ctx, key, IfMatch(entry))
+		} else if entry == nil {
+			result, err = m.Put(ctx, key, value, IfNotSet())
+		} else {
+			result, err = m.Put(ctx, key, value, IfMatch(entry))
+		}
+		if err == nil {
+			return result, nil
+		}
+		if !errors.IsConflict(err) && !errors.IsNotFound(err) && !errors.IsAlreadyExists(err) {
+			return nil, err
+		}
+
+		if options.maxRetries >= 0 && attempt >= options.maxRetries {
+			return nil, errors.NewConflict("failed to update key '%s' after %d attempts", key, attempt+1)
+		}
+		log.Debugf("Retrying conflicting update to key '%s'", key)
+
+		// Sleep for a random duration up to the current backoff to avoid retrying in lockstep with other writers
+		delay := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, errors.From(ctx.Err())
+		}
+		backoff *= 2
+		if backoff > options.maxBackoff {
+			backoff = options.maxBackoff
+		}
+	}
+}
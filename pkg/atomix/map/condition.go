@@ -0,0 +1,141 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package _map //nolint:golint
+
+import (
+	"bytes"
+	"context"
+	api "github.com/atomix/atomix-api/go/atomix/primitive/map"
+	"github.com/atomix/atomix-go-framework/pkg/atomix/errors"
+)
+
+// IfValue returns an option that applies a write only if the current value of the key equals the given value
+// The server only supports version preconditions, so the entry is read and compared by the client and the
+// write is applied with an IfMatch precondition for the version that was read. If the entry is changed
+// after it's read, the write fails with a conflict error.
+func IfValue(value []byte) ConditionOption {
+	return ConditionOption{
+		check: func(key string, entry *Entry) error {
+			if entry == nil {
+				return errors.NewNotFound("key '%s' not found", key)
+			}
+			if !bytes.Equal(entry.Value, value) {
+				return errors.NewConflict("value of key '%s' does not match", key)
+			}
+			return nil
+		},
+	}
+}
+
+// IfExists returns an option that applies a write only if the key exists
+// Like IfValue, the entry is read by the client and the write is applied with an IfMatch precondition for
+// the version that was read.
+func IfExists() ConditionOption {
+	return ConditionOption{
+		check: func(key string, entry *Entry) error {
+			if entry == nil {
+				return errors.NewNotFound("key '%s' not found", key)
+			}
+			return nil
+		},
+	}
+}
+
+// ConditionOption is an implementation of PutOption and RemoveOption that checks the current entry for the key
+// before the write is applied
+type ConditionOption struct {
+	check func(key string, entry *Entry) error
+}
+
+func (o ConditionOption) beforePut(request *api.PutRequest) {
+
+}
+
+func (o ConditionOption) afterPut(response *api.PutResponse) {
+
+}
+
+func (o ConditionOption) beforeRemove(request *api.RemoveRequest) {
+
+}
+
+func (o ConditionOption) afterRemove(response *api.RemoveResponse) {
+
+}
+
+// getFunc reads the entry for a key
+type getFunc func(ctx context.Context, key string, opts ...GetOption) (*Entry, error)
+
+// checkConditions reads the entry for the given key and checks it against the given conditions
+// Every condition requires the key to exist, so the entry returned once the conditions are met is never nil.
+func checkConditions(ctx context.Context, key string, get getFunc, conditions []ConditionOption) (*Entry, error) {
+	entry, err := get(ctx, key)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return nil, err
+		}
+		entry = nil
+	}
+	for _, condition := range conditions {
+		if err := condition.check(key, entry); err != nil {
+			return nil, err
+		}
+	}
+	return entry, nil
+}
+
+// resolvePutConditions checks the conditions in the given options against the current entry for the key
+// The conditions are replaced with an IfMatch option for the entry that was checked.
+func resolvePutConditions(ctx context.Context, key string, get getFunc, opts []PutOption) ([]PutOption, error) {
+	var conditions []ConditionOption
+	resolved := make([]PutOption, 0, len(opts))
+	for _, opt := range opts {
+		if condition, ok := opt.(ConditionOption); ok {
+			conditions = append(conditions, condition)
+		} else {
+			resolved = append(resolved, opt)
+		}
+	}
+	if len(conditions) == 0 {
+		return opts, nil
+	}
+	entry, err := checkConditions(ctx, key, get, conditions)
+	if err != nil {
+		return nil, err
+	}
+	return append(resolved, IfMatch(entry)), nil
+}
+
+// resolveRemoveConditions checks the conditions in the given options against the current entry for the key
+// The conditions are replaced with an IfMatch option for the entry that was checked.
+func resolveRemoveConditions(ctx context.Context, key string, get getFunc, opts []RemoveOption) ([]RemoveOption, error) {
+	var conditions []ConditionOption
+	resolved := make([]RemoveOption, 0, len(opts))
+	for _, opt := range opts {
+		if condition, ok := opt.(ConditionOption); ok {
+			conditions = append(conditions, condition)
+		} else {
+			resolved = append(resolved, opt)
+		}
+	}
+	if len(conditions) == 0 {
+		return opts, nil
+	}
+	entry, err := checkConditions(ctx, key, get, conditions)
+	if err != nil {
+		return nil, err
+	}
+	return append(resolved, IfMatch(entry)), nil
+}
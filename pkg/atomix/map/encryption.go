@@ -0,0 +1,59 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package _map //nolint:golint
+
+import (
+	"context"
+	"github.com/atomix/atomix-go-framework/pkg/atomix/errors"
+	"io"
+)
+
+// Reencrypt rewrites all the entries in the given map under the map's current encryption key
+// The map must have been created with primitive.WithEncryption, and the key provider must still be able to
+// provide the keys with which existing entries were encrypted. Each entry is written back using IfMatch, so
+// entries that are concurrently updated or removed (and are thus already encrypted with the current key)
+// are skipped. The number of entries rewritten is returned. If the entries cannot be read, no entry is rewritten
+// and the error is returned.
+func Reencrypt(ctx context.Context, m Map) (int, error) {
+	iterator, err := m.Iterate(ctx)
+	if err != nil {
+		return 0, err
+	}
+	entries := make([]Entry, 0)
+	for {
+		entry, err := iterator.Next(ctx)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			iterator.Close()
+			return 0, err
+		}
+		entries = append(entries, entry)
+	}
+	iterator.Close()
+
+	count := 0
+	for _, entry := range entries {
+		_, err := m.Put(ctx, entry.Key, entry.Value, IfMatch(entry))
+		if err != nil {
+			if errors.IsConflict(err) || errors.IsNotFound(err) {
+				continue
+			}
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
@@ -0,0 +1,176 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package _map //nolint:golint
+
+import (
+	"container/heap"
+	"context"
+	"github.com/atomix/atomix-go-framework/pkg/atomix/errors"
+	"io"
+)
+
+// EntryIterator iterates over the entries in a map
+type EntryIterator interface {
+	// Next returns the next entry
+	// Once all entries have been read, io.EOF is returned. If the iteration fails, the error is returned
+	// and all subsequent calls return the same error. If the context is done before the next entry is
+	// read, the iteration is aborted and the context error is returned.
+	Next(ctx context.Context) (Entry, error)
+
+	// Close closes the iterator, cancelling the underlying stream
+	Close()
+}
+
+// entryIterator is an EntryIterator that reads entries using a next function
+type entryIterator struct {
+	next  func(ctx context.Context) (Entry, error)
+	close func()
+	err   error
+}
+
+func (i *entryIterator) Next(ctx context.Context) (Entry, error) {
+	if i.err != nil {
+		return Entry{}, i.err
+	}
+	entry, err := i.next(ctx)
+	if err != nil {
+		i.err = err
+		i.close()
+		return Entry{}, err
+	}
+	return entry, nil
+}
+
+func (i *entryIterator) Close() {
+	if i.err == nil {
+		i.err = errors.NewCanceled("iterator is closed")
+	}
+	i.close()
+}
+
+// pageIterator returns an iterator over the requested page of the entries read from the given iterator
+// If the options require the entries to be ordered, all entries are read when the first entry is requested,
+// keeping only the entries of the page, which are then returned in key order. When a limit is set, the page is
+// held in a bounded max-heap, so reading a page takes memory proportional to the limit rather than to the
+// size of the map.
+func pageIterator(iterator *entryIterator, options entriesOptions) *entryIterator {
+	if !options.ordered() {
+		return iterator
+	}
+
+	var entries []Entry
+	return &entryIterator{
+		next: func(ctx context.Context) (Entry, error) {
+			if entries == nil {
+				page := &entryHeap{}
+				for {
+					entry, err := iterator.Next(ctx)
+					if err == io.EOF {
+						break
+					} else if err != nil {
+						return Entry{}, err
+					}
+					if options.limit > 0 && page.Len() == options.limit {
+						if entry.Key >= (*page)[0].Key {
+							continue
+						}
+						(*page)[0] = entry
+						heap.Fix(page, 0)
+					} else {
+						heap.Push(page, entry)
+					}
+				}
+				entries = make([]Entry, page.Len())
+				for i := len(entries) - 1; i >= 0; i-- {
+					entries[i] = heap.Pop(page).(Entry)
+				}
+			}
+			if len(entries) == 0 {
+				return Entry{}, io.EOF
+			}
+			entry := entries[0]
+			entries = entries[1:]
+			return entry, nil
+		},
+		close: iterator.Close,
+	}
+}
+
+// entryHeap is a max-heap of entries ordered by key
+type entryHeap []Entry
+
+func (h entryHeap) Len() int {
+	return len(h)
+}
+
+func (h entryHeap) Less(i, j int) bool {
+	return h[i].Key > h[j].Key
+}
+
+func (h entryHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+}
+
+func (h *entryHeap) Push(x interface{}) {
+	*h = append(*h, x.(Entry))
+}
+
+func (h *entryHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	entry := old[n-1]
+	*h = old[:n-1]
+	return entry
+}
+
+// pushEntries pushes the entries read from the given iterator onto the given channel
+// The channel is closed once all entries have been read, the iteration fails, or the context is done.
+func pushEntries(ctx context.Context, iterator EntryIterator, ch chan<- Entry) {
+	defer close(ch)
+	defer iterator.Close()
+	for {
+		entry, err := iterator.Next(ctx)
+		if err == io.EOF {
+			return
+		} else if err != nil {
+			if !errors.IsCanceled(err) && !errors.IsTimeout(err) {
+				log.Errorf("Entries failed: %v", err)
+			}
+			return
+		}
+		select {
+		case ch <- entry:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// pushKeys pushes the keys of the entries read from the given iterator onto the given channel
+func pushKeys(ctx context.Context, iterator EntryIterator, ch chan<- string) {
+	entryCh := make(chan Entry)
+	go pushEntries(ctx, iterator, entryCh)
+	defer close(ch)
+	for entry := range entryCh {
+		select {
+		case ch <- entry.Key:
+		case <-ctx.Done():
+			// Drain the entries so the reader can observe the context and exit
+			for range entryCh {
+			}
+			return
+		}
+	}
+}
@@ -0,0 +1,183 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package _map //nolint:golint
+
+import (
+	"context"
+	"github.com/atomix/atomix-go-framework/pkg/atomix/errors"
+	"sync"
+	"time"
+)
+
+// negativeSweepSize is the number of negative entries above which expired entries are removed
+const negativeSweepSize = 1000
+
+// Loader loads the value for a key that's missing from the map
+// If there's no value for the key, the loader returns a nil value or a NotFound error.
+type Loader func(ctx context.Context, key string) ([]byte, error)
+
+// LoadingCache is a read-through cache backed by a map
+// Keys missing from the map are loaded by a Loader and written to the map, so the map can be shared as a cache
+// in front of a slow data source by many clients.
+type LoadingCache interface {
+	// Get gets the entry for the given key, loading the key if it's missing from the map
+	// If the loader has no value for the key, a NotFound error is returned.
+	Get(ctx context.Context, key string) (*Entry, error)
+
+	// Invalidate removes the given key from the cache
+	Invalidate(ctx context.Context, key string) error
+}
+
+// NewLoadingCache creates a new LoadingCache that loads keys missing from the given map with the given loader
+// Concurrent loads of the same key within the process are coalesced into a single call to the loader. The shared
+// load is not bound to the context of any one reader, and is cancelled only once every reader waiting for it has
+// given up. Loaded values are written to the map only if the key is still missing, so when clients load a key
+// concurrently they all read the value that was written first. Keys for which the loader has no value can be
+// remembered by the process for a time with the WithNegativeTTL option.
+func NewLoadingCache(m Map, loader Loader, opts ...LoadingCacheOption) LoadingCache {
+	options := loadingCacheOptions{}
+	for i := range opts {
+		opts[i].applyLoadingCache(&options)
+	}
+	return &loadingCache{
+		m:        m,
+		loader:   loader,
+		options:  options,
+		loads:    make(map[string]*loadCall),
+		negative: make(map[string]time.Time),
+	}
+}
+
+// loadCall is a load of a key shared by concurrent readers
+// The load is cancelled once all the readers waiting for it are done.
+type loadCall struct {
+	done   chan struct{}
+	refs   int
+	cancel context.CancelFunc
+	entry  *Entry
+	err    error
+}
+
+// loadingCache is a LoadingCache backed by a map
+type loadingCache struct {
+	m        Map
+	loader   Loader
+	options  loadingCacheOptions
+	mu       sync.Mutex
+	loads    map[string]*loadCall
+	negative map[string]time.Time
+}
+
+func (c *loadingCache) Get(ctx context.Context, key string) (*Entry, error) {
+	entry, err := c.m.Get(ctx, key)
+	if err == nil || !errors.IsNotFound(err) {
+		return entry, err
+	}
+
+	c.mu.Lock()
+	if expire, ok := c.negative[key]; ok {
+		if time.Now().Before(expire) {
+			c.mu.Unlock()
+			return nil, errors.NewNotFound("key '%s' not found", key)
+		}
+		delete(c.negative, key)
+	}
+	call, ok := c.loads[key]
+	if !ok {
+		loadCtx, cancel := context.WithCancel(context.Background())
+		call = &loadCall{
+			done:   make(chan struct{}),
+			cancel: cancel,
+		}
+		c.loads[key] = call
+		go func() {
+			entry, err := c.load(loadCtx, key)
+			c.mu.Lock()
+			if c.loads[key] == call {
+				delete(c.loads, key)
+			}
+			call.entry, call.err = entry, err
+			c.mu.Unlock()
+			close(call.done)
+			cancel()
+		}()
+	}
+	call.refs++
+	c.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.entry, call.err
+	case <-ctx.Done():
+		c.mu.Lock()
+		call.refs--
+		if call.refs == 0 {
+			// No reader is waiting for the load, so it's cancelled and the next reader starts a new load
+			if c.loads[key] == call {
+				delete(c.loads, key)
+			}
+			call.cancel()
+		}
+		c.mu.Unlock()
+		return nil, errors.From(ctx.Err())
+	}
+}
+
+// load loads the given key and writes it to the map if it's still missing
+func (c *loadingCache) load(ctx context.Context, key string) (*Entry, error) {
+	value, err := c.loader(ctx, key)
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, err
+	}
+	if value == nil {
+		if c.options.negativeTTL > 0 {
+			c.mu.Lock()
+			c.addNegative(key)
+			c.mu.Unlock()
+		}
+		return nil, errors.NewNotFound("key '%s' not found", key)
+	}
+
+	var putOpts []PutOption
+	if c.options.ttl > 0 {
+		putOpts = append(putOpts, WithTTL(c.options.ttl))
+	}
+	entry, _, err := c.m.PutIfAbsent(ctx, key, value, putOpts...)
+	return entry, err
+}
+
+// addNegative remembers that the given key has no value, removing expired keys if necessary
+func (c *loadingCache) addNegative(key string) {
+	now := time.Now()
+	if len(c.negative) >= negativeSweepSize {
+		for negativeKey, expire := range c.negative {
+			if !now.Before(expire) {
+				delete(c.negative, negativeKey)
+			}
+		}
+	}
+	c.negative[key] = now.Add(c.options.negativeTTL)
+}
+
+func (c *loadingCache) Invalidate(ctx context.Context, key string) error {
+	c.mu.Lock()
+	delete(c.negative, key)
+	c.mu.Unlock()
+	_, err := c.m.Remove(ctx, key)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/atomix/atomix-go-framework/pkg/atomix/meta"
 	"google.golang.org/grpc"
 	"io"
+	"time"
 )
 
 // Type is the map type
@@ -50,6 +51,50 @@ type Map interface {
 	// Remove removes a key from the map
 	Remove(ctx context.Context, key string, opts ...RemoveOption) (*Entry, error)
 
+	// PutAll puts the given entries in the map
+	// A result is returned for each entry in the order of the given entries. Entries with a non-zero
+	// revision are put only if the revision matches the current revision of the entry.
+	PutAll(ctx context.Context, entries []Entry, opts ...BatchOption) ([]BatchResult, error)
+
+	// GetAll gets the entries for the given keys
+	// A result is returned for each key in the order of the given keys.
+	GetAll(ctx context.Context, keys []string, opts ...BatchOption) ([]BatchResult, error)
+
+	// RemoveAll removes the given keys from the map
+	// A result is returned for each key in the order of the given keys.
+	RemoveAll(ctx context.Context, keys []string, opts ...BatchOption) ([]BatchResult, error)
+
+	// Compute computes a new value for the given key from its current entry
+	// The function is called with a nil entry if the key is not present, and the key is removed if the function
+	// returns a nil value. The update is applied only if the key has not been modified since it was read, and is
+	// otherwise retried with the function called again with the new entry. The updated entry is returned.
+	Compute(ctx context.Context, key string, f ComputeFunc, opts ...ComputeOption) (*Entry, error)
+
+	// ComputeIfAbsent computes a value for the given key if the key is not present
+	// If the key is present, the current entry is returned and the function is not called.
+	ComputeIfAbsent(ctx context.Context, key string, f func(key string) ([]byte, error), opts ...ComputeOption) (*Entry, error)
+
+	// ComputeIfPresent computes a new value for the given key if the key is present
+	// If the key is not present, a NotFound error is returned.
+	ComputeIfPresent(ctx context.Context, key string, f ComputeFunc, opts ...ComputeOption) (*Entry, error)
+
+	// Merge puts the given value if the key is not present, or otherwise merges it into the current value
+	Merge(ctx context.Context, key string, value []byte, f MergeFunc, opts ...ComputeOption) (*Entry, error)
+
+	// GetAndPut puts the given value for the given key and returns the entry it replaced
+	// The previous entry is nil if the key was not present. The value is put only if the key has not been
+	// modified since it was read, and is otherwise retried.
+	GetAndPut(ctx context.Context, key string, value []byte, opts ...ComputeOption) (*Entry, error)
+
+	// PutIfAbsent puts the given value for the given key if the key is not present
+	// If the value was put, the new entry is returned with a true flag. If the key is already present, the
+	// existing entry is returned with a false flag.
+	PutIfAbsent(ctx context.Context, key string, value []byte, opts ...PutOption) (*Entry, bool, error)
+
+	// Txn returns a new best-effort multi-key write on the map
+	// Transactions are not atomic: writes are applied one key at a time and rolled back on failure.
+	Txn() Transaction
+
 	// Len returns the number of entries in the map
 	Len(ctx context.Context) (int, error)
 
@@ -59,7 +104,20 @@ type Map interface {
 	// Entries lists the entries in the map
 	// This is a non-blocking method. If the method returns without error, key/value paids will be pushed on to the
 	// given channel and the channel will be closed once all entries have been read from the map.
-	Entries(ctx context.Context, ch chan<- Entry) error
+	// Each call reads the entries from a single snapshot of the map. Options can be provided to filter the entries
+	// by key prefix or range and to page through the entries in key order. Pages are read from separate snapshots.
+	Entries(ctx context.Context, ch chan<- Entry, opts ...EntriesOption) error
+
+	// Iterate returns an iterator over the entries in the map
+	// Each call reads the entries from a single snapshot of the map. Iterate accepts the same options as Entries.
+	// The iterator must be closed once the caller is done with it.
+	Iterate(ctx context.Context, opts ...EntriesOption) (EntryIterator, error)
+
+	// Keys lists the keys in the map
+	// This is a non-blocking method. If the method returns without error, keys will be pushed on to the given
+	// channel and the channel will be closed once all keys have been read from the map. Keys accepts the same
+	// options as Entries, but does not decode entry values. Entry values are still read from the map.
+	Keys(ctx context.Context, ch chan<- string, opts ...EntriesOption) error
 
 	// Watch watches the map for changes
 	// This is a non-blocking method. If the method returns without error, map events will be pushed onto
@@ -74,13 +132,28 @@ func newEntry(entry *api.Entry) *Entry {
 	if entry == nil {
 		return nil
 	}
+	var value []byte
+	var ttl time.Duration
+	if entry.Value != nil {
+		value = entry.Value.Value
+		// The TTL is reported relative to the expiration time, so the remaining time is negated
+		if entry.Value.TTL != nil && *entry.Value.TTL < 0 {
+			ttl = -*entry.Value.TTL
+		}
+	}
 	return &Entry{
 		ObjectMeta: meta.FromProto(entry.Key.ObjectMeta),
 		Key:        entry.Key.Key,
-		Value:      entry.Value.Value,
+		Value:      value,
+		TTL:        ttl,
 	}
 }
 
+// isExpired returns a bool indicating whether the given entry was removed due to expiration of its TTL
+func isExpired(entry *api.Entry) bool {
+	return entry.Value != nil && entry.Value.TTL != nil && *entry.Value.TTL >= 0
+}
+
 // Entry is a versioned key/value pair
 type Entry struct {
 	meta.ObjectMeta
@@ -90,6 +163,9 @@ type Entry struct {
 
 	// Value is the value of the pair
 	Value []byte
+
+	// TTL is the remaining time to live of the entry, or zero if the entry does not expire
+	TTL time.Duration
 }
 
 func (kv Entry) String() string {
@@ -111,6 +187,18 @@ const (
 
 	// EventReplay indicates a key was replayed
 	EventReplay EventType = "replay"
+
+	// EventExpire indicates a key was removed from the map when its TTL expired
+	EventExpire EventType = "expire"
+
+	// EventSynced indicates all the entries in the map have been replayed
+	// The synced event carries no entry and is delivered regardless of the watch filters.
+	EventSynced EventType = "synced"
+
+	// EventResync indicates the watch stream was re-established and events may have been missed
+	// A resync event is followed by an event for each key that changed while the stream was down. The resync
+	// event carries no entry and is delivered regardless of the watch filters.
+	EventResync EventType = "resync"
 )
 
 // Event is a map change event
@@ -120,6 +208,11 @@ type Event struct {
 
 	// Entry is the event entry
 	Entry Entry
+
+	// PrevEntry is the entry replaced or removed by the change
+	// The previous entry is only populated for watches opened with the WithPrevValues option, and is nil
+	// if the key did not exist before the change or its previous entry is not known.
+	PrevEntry *Entry
 }
 
 // New creates a new partitioned Map
@@ -138,6 +231,12 @@ func New(ctx context.Context, name string, conn *grpc.ClientConn, opts ...primit
 	if err := m.Create(ctx); err != nil {
 		return nil, err
 	}
+	if options.chunkSize > 0 {
+		return &chunkedMap{
+			_map:      m,
+			chunkSize: options.chunkSize,
+		}, nil
+	}
 	return m, nil
 }
 
@@ -147,7 +246,29 @@ type _map struct {
 	options newMapOptions
 }
 
+// decodeEntry converts the given entry to an Entry, decrypting the value if necessary
+func (m *_map) decodeEntry(entry *api.Entry) (*Entry, error) {
+	result := newEntry(entry)
+	if result == nil {
+		return nil, nil
+	}
+	value, err := m.Decrypt(result.Value)
+	if err != nil {
+		return nil, err
+	}
+	result.Value = value
+	return result, nil
+}
+
 func (m *_map) Put(ctx context.Context, key string, value []byte, opts ...PutOption) (*Entry, error) {
+	opts, err := resolvePutConditions(ctx, key, m.Get, opts)
+	if err != nil {
+		return nil, err
+	}
+	value, err = m.Encrypt(value)
+	if err != nil {
+		return nil, err
+	}
 	request := &api.PutRequest{
 		Headers: m.GetHeaders(),
 		Entry: api.Entry{
@@ -169,7 +290,7 @@ func (m *_map) Put(ctx context.Context, key string, value []byte, opts ...PutOpt
 	for i := range opts {
 		opts[i].afterPut(response)
 	}
-	return newEntry(&response.Entry), nil
+	return m.decodeEntry(&response.Entry)
 }
 
 func (m *_map) Get(ctx context.Context, key string, opts ...GetOption) (*Entry, error) {
@@ -187,10 +308,14 @@ func (m *_map) Get(ctx context.Context, key string, opts ...GetOption) (*Entry,
 	for i := range opts {
 		opts[i].afterGet(response)
 	}
-	return newEntry(&response.Entry), nil
+	return m.decodeEntry(&response.Entry)
 }
 
 func (m *_map) Remove(ctx context.Context, key string, opts ...RemoveOption) (*Entry, error) {
+	opts, err := resolveRemoveConditions(ctx, key, m.Get, opts)
+	if err != nil {
+		return nil, err
+	}
 	request := &api.RemoveRequest{
 		Headers: m.GetHeaders(),
 		Key: api.Key{
@@ -207,7 +332,47 @@ func (m *_map) Remove(ctx context.Context, key string, opts ...RemoveOption) (*E
 	for i := range opts {
 		opts[i].afterRemove(response)
 	}
-	return newEntry(&response.Entry), nil
+	return m.decodeEntry(&response.Entry)
+}
+
+func (m *_map) PutAll(ctx context.Context, entries []Entry, opts ...BatchOption) ([]BatchResult, error) {
+	return putAll(ctx, m, entries, opts...)
+}
+
+func (m *_map) GetAll(ctx context.Context, keys []string, opts ...BatchOption) ([]BatchResult, error) {
+	return getAll(ctx, m, keys, opts...)
+}
+
+func (m *_map) RemoveAll(ctx context.Context, keys []string, opts ...BatchOption) ([]BatchResult, error) {
+	return removeAll(ctx, m, keys, opts...)
+}
+
+func (m *_map) Compute(ctx context.Context, key string, f ComputeFunc, opts ...ComputeOption) (*Entry, error) {
+	return compute(ctx, m, key, f, opts...)
+}
+
+func (m *_map) ComputeIfAbsent(ctx context.Context, key string, f func(key string) ([]byte, error), opts ...ComputeOption) (*Entry, error) {
+	return computeIfAbsent(ctx, m, key, f, opts...)
+}
+
+func (m *_map) ComputeIfPresent(ctx context.Context, key string, f ComputeFunc, opts ...ComputeOption) (*Entry, error) {
+	return computeIfPresent(ctx, m, key, f, opts...)
+}
+
+func (m *_map) Merge(ctx context.Context, key string, value []byte, f MergeFunc, opts ...ComputeOption) (*Entry, error) {
+	return merge(ctx, m, key, value, f, opts...)
+}
+
+func (m *_map) GetAndPut(ctx context.Context, key string, value []byte, opts ...ComputeOption) (*Entry, error) {
+	return getAndPut(ctx, m, key, value, opts...)
+}
+
+func (m *_map) PutIfAbsent(ctx context.Context, key string, value []byte, opts ...PutOption) (*Entry, bool, error) {
+	return putIfAbsent(ctx, m, key, value, opts...)
+}
+
+func (m *_map) Txn() Transaction {
+	return newTransaction(m)
 }
 
 func (m *_map) Len(ctx context.Context) (int, error) {
@@ -232,33 +397,79 @@ func (m *_map) Clear(ctx context.Context) error {
 	return nil
 }
 
-func (m *_map) Entries(ctx context.Context, ch chan<- Entry) error {
+func (m *_map) Iterate(ctx context.Context, opts ...EntriesOption) (EntryIterator, error) {
+	options := newEntriesOptions(opts...)
+	iterator, err := m.openEntries(ctx, options, true)
+	if err != nil {
+		return nil, err
+	}
+	return pageIterator(iterator, options), nil
+}
+
+func (m *_map) Entries(ctx context.Context, ch chan<- Entry, opts ...EntriesOption) error {
+	iterator, err := m.Iterate(ctx, opts...)
+	if err != nil {
+		return err
+	}
+	go pushEntries(ctx, iterator, ch)
+	return nil
+}
+
+func (m *_map) Keys(ctx context.Context, ch chan<- string, opts ...EntriesOption) error {
+	options := newEntriesOptions(opts...)
+	iterator, err := m.openEntries(ctx, options, false)
+	if err != nil {
+		return err
+	}
+	go pushKeys(ctx, pageIterator(iterator, options), ch)
+	return nil
+}
+
+// openEntries opens a stream of the entries matching the given options
+// If decode is false, entry values are returned as stored.
+func (m *_map) openEntries(ctx context.Context, options entriesOptions, decode bool) (*entryIterator, error) {
+	if options.err != nil {
+		return nil, options.err
+	}
 	request := &api.EntriesRequest{
 		Headers: m.GetHeaders(),
 	}
+	ctx, cancel := context.WithCancel(ctx)
 	stream, err := m.client.Entries(ctx, request)
 	if err != nil {
-		return errors.From(err)
+		cancel()
+		return nil, errors.From(err)
 	}
 
-	go func() {
-		defer close(ch)
-		for {
-			response, err := stream.Recv()
-			if err == io.EOF {
-				return
-			} else if err != nil {
-				log.Errorf("Entries failed: %v", err)
-			} else {
-				ch <- Entry{
-					ObjectMeta: meta.FromProto(response.Entry.Key.ObjectMeta),
-					Key:        response.Entry.Key.Key,
-					Value:      response.Entry.Value.Value,
+	return &entryIterator{
+		next: func(ctx context.Context) (Entry, error) {
+			for {
+				var response *api.EntriesResponse
+				err := primitive.RecvWithContext(ctx, cancel, func() error {
+					var err error
+					response, err = stream.Recv()
+					return err
+				})
+				if err == io.EOF {
+					return Entry{}, io.EOF
+				} else if err != nil {
+					return Entry{}, errors.From(err)
+				}
+				if !options.matches(response.Entry.Key.Key) {
+					continue
+				}
+				if !decode {
+					return *newEntry(&response.Entry), nil
+				}
+				entry, err := m.decodeEntry(&response.Entry)
+				if err != nil {
+					return Entry{}, err
 				}
+				return *entry, nil
 			}
-		}
-	}()
-	return nil
+		},
+		close: cancel,
+	}, nil
 }
 
 func (m *_map) Watch(ctx context.Context, ch chan<- Event, opts ...WatchOption) error {
@@ -269,20 +480,85 @@ func (m *_map) Watch(ctx context.Context, ch chan<- Event, opts ...WatchOption)
 		opts[i].beforeWatch(request)
 	}
 
+	var filters []eventFilter
+	for i := range opts {
+		if filter, ok := opts[i].(eventFilter); ok {
+			filters = append(filters, filter)
+		}
+	}
+
+	// Replay is performed by the client so the end of the replay can be signaled to the consumer
+	replay := request.Replay
+	request.Replay = false
+
 	stream, err := m.client.Events(ctx, request)
 	if err != nil {
 		return errors.From(err)
 	}
 
+	// To resynchronize a watch after the stream is re-established, the revision of each key must be tracked
+	// Replayed entries are tracked to discard events for changes already reflected in the replay.
+	// To populate the previous entry of events, the entries in the map must be tracked
+	var tracker *revisionTracker
+	var view *entryView
+	if isPrevValues(opts) {
+		view = newEntryView()
+	}
+	if replay {
+		tracker = newRevisionTracker()
+	} else if isAutoReconnect(opts) || view != nil {
+		entries, err := m.readEntries(ctx, request.Key)
+		if err != nil {
+			return err
+		}
+		if isAutoReconnect(opts) {
+			tracker = newRevisionTracker()
+		}
+		for _, entry := range entries {
+			if tracker != nil {
+				tracker.update(Event{Type: EventReplay, Entry: entry})
+			}
+			if view != nil {
+				view.update(&Event{Type: EventReplay, Entry: entry})
+			}
+		}
+	}
+
+	buffer := primitive.NewEventBuffer(primitive.GetWatchBuffer(opts))
+	go buffer.Forward(ctx, ch)
+
+	send := func(event Event) bool {
+		if event.Type == EventResync {
+			return buffer.Push(ctx, "", event)
+		}
+		if view != nil {
+			view.update(&event)
+		}
+		if !filterEvent(event, filters) {
+			return true
+		}
+		return buffer.Push(ctx, event.Entry.Key, event)
+	}
+	push := func(event Event) bool {
+		if tracker != nil && !tracker.update(event) {
+			return true
+		}
+		return send(event)
+	}
+
 	openCh := make(chan struct{})
 	go func() {
-		defer close(ch)
+		defer buffer.Close()
 		open := false
 		defer func() {
 			if !open {
 				close(openCh)
 			}
 		}()
+		cancel := func() {}
+		defer func() {
+			cancel()
+		}()
 		for {
 			response, err := stream.Recv()
 			if err == io.EOF ||
@@ -290,39 +566,45 @@ func (m *_map) Watch(ctx context.Context, ch chan<- Event, opts ...WatchOption)
 				errors.IsTimeout(errors.From(err)) {
 				return
 			} else if err != nil {
-				log.Errorf("Watch failed: %v", err)
-				return
+				if tracker == nil || !isAutoReconnect(opts) {
+					log.Errorf("Watch failed: %v", err)
+					return
+				}
+				log.Warnf("Watch failed; reconnecting: %v", err)
+				cancel()
+				stream, cancel, err = m.resync(ctx, request, tracker, send)
+				if err != nil {
+					cancel = func() {}
+					return
+				}
 			} else {
 				if !open {
 					close(openCh)
 					open = true
+					if replay {
+						if err := m.replay(ctx, request.Key, tracker, send); err != nil {
+							if ctx.Err() == nil {
+								log.Errorf("Watch failed: %v", err)
+							}
+							return
+						}
+						if !buffer.Push(ctx, "", Event{Type: EventSynced}) {
+							return
+						}
+					}
 				}
 
 				for i := range opts {
 					opts[i].afterWatch(response)
 				}
 
-				switch response.Event.Type {
-				case api.Event_INSERT:
-					ch <- Event{
-						Type:  EventInsert,
-						Entry: *newEntry(&response.Event.Entry),
-					}
-				case api.Event_UPDATE:
-					ch <- Event{
-						Type:  EventUpdate,
-						Entry: *newEntry(&response.Event.Entry),
-					}
-				case api.Event_REMOVE:
-					ch <- Event{
-						Type:  EventRemove,
-						Entry: *newEntry(&response.Event.Entry),
-					}
-				case api.Event_REPLAY:
-					ch <- Event{
-						Type:  EventReplay,
-						Entry: *newEntry(&response.Event.Entry),
-					}
+				event, err := m.decodeEvent(&response.Event)
+				if err != nil {
+					log.Errorf("Failed to decode event: %v", err)
+					continue
+				}
+				if event != nil && !push(*event) {
+					return
 				}
 			}
 		}
@@ -335,3 +617,32 @@ func (m *_map) Watch(ctx context.Context, ch chan<- Event, opts ...WatchOption)
 		return ctx.Err()
 	}
 }
+
+// decodeEvent converts the given event to an Event, returning nil if the event is not a change event
+func (m *_map) decodeEvent(event *api.Event) (*Event, error) {
+	var eventType EventType
+	switch event.Type {
+	case api.Event_INSERT:
+		eventType = EventInsert
+	case api.Event_UPDATE:
+		eventType = EventUpdate
+	case api.Event_REMOVE:
+		if isExpired(&event.Entry) {
+			eventType = EventExpire
+		} else {
+			eventType = EventRemove
+		}
+	case api.Event_REPLAY:
+		eventType = EventReplay
+	default:
+		return nil, nil
+	}
+	entry, err := m.decodeEntry(&event.Entry)
+	if err != nil {
+		return nil, err
+	}
+	return &Event{
+		Type:  eventType,
+		Entry: *entry,
+	}, nil
+}
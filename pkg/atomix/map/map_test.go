@@ -16,13 +16,23 @@ package _map //nolint:golint
 
 import (
 	"context"
+	"fmt"
 	primitiveapi "github.com/atomix/atomix-api/go/atomix/primitive"
+	api "github.com/atomix/atomix-api/go/atomix/primitive/map"
+	"github.com/atomix/atomix-go-client/pkg/atomix/primitive"
 	"github.com/atomix/atomix-go-client/pkg/atomix/util/test"
 	"github.com/atomix/atomix-go-framework/pkg/atomix/errors"
 	"github.com/atomix/atomix-go-framework/pkg/atomix/logging"
 	"github.com/atomix/atomix-go-framework/pkg/atomix/meta"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"io"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestMapOperations(t *testing.T) {
@@ -278,3 +288,1631 @@ func TestMapStreams(t *testing.T) {
 
 	assert.NoError(t, test.Stop())
 }
+
+func TestMapEncryption(t *testing.T) {
+	logging.SetLevel(logging.DebugLevel)
+
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestMapEncryption",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn1, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	conn2, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	keys := primitive.NewKeyRing("key-1", []byte("0123456789abcdef"))
+	encrypted, err := New(context.TODO(), "TestMapEncryption", conn1, primitive.WithEncryption(keys))
+	assert.NoError(t, err)
+
+	plain, err := New(context.TODO(), "TestMapEncryption", conn2)
+	assert.NoError(t, err)
+
+	ch := make(chan Event)
+	err = encrypted.Watch(context.Background(), ch)
+	assert.NoError(t, err)
+
+	kv, err := encrypted.Put(context.Background(), "foo", []byte("bar"))
+	assert.NoError(t, err)
+	assert.Equal(t, "bar", string(kv.Value))
+
+	event := <-ch
+	assert.Equal(t, EventInsert, event.Type)
+	assert.Equal(t, "bar", string(event.Entry.Value))
+
+	kv, err = encrypted.Get(context.Background(), "foo")
+	assert.NoError(t, err)
+	assert.Equal(t, "bar", string(kv.Value))
+
+	raw, err := plain.Get(context.Background(), "foo")
+	assert.NoError(t, err)
+	assert.NotEqual(t, "bar", string(raw.Value))
+
+	entries := make(chan Entry)
+	err = encrypted.Entries(context.Background(), entries)
+	assert.NoError(t, err)
+	entry, ok := <-entries
+	assert.True(t, ok)
+	assert.Equal(t, "bar", string(entry.Value))
+	_, ok = <-entries
+	assert.False(t, ok)
+
+	keys.AddKey("key-2", []byte("fedcba9876543210"))
+	assert.NoError(t, keys.SetCurrentKey("key-2"))
+
+	count, err := Reencrypt(context.Background(), encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	event = <-ch
+	assert.Equal(t, EventUpdate, event.Type)
+	assert.Equal(t, "bar", string(event.Entry.Value))
+
+	assert.NoError(t, keys.RemoveKey("key-1"))
+	kv, err = encrypted.Get(context.Background(), "foo")
+	assert.NoError(t, err)
+	assert.Equal(t, "bar", string(kv.Value))
+
+	keys.AddKey("key-3", []byte("0011223344556677"))
+	assert.NoError(t, keys.SetCurrentKey("key-3"))
+	assert.NoError(t, keys.RemoveKey("key-2"))
+	_, err = Reencrypt(context.Background(), encrypted)
+	assert.Error(t, err)
+
+	assert.NoError(t, test.Stop())
+}
+
+func TestChunkedMap(t *testing.T) {
+	logging.SetLevel(logging.DebugLevel)
+
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestChunkedMap",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn1, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	conn2, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	chunked, err := New(context.TODO(), "TestChunkedMap", conn1, WithChunkSize(8))
+	assert.NoError(t, err)
+
+	raw, err := New(context.TODO(), "TestChunkedMap", conn2)
+	assert.NoError(t, err)
+
+	ch := make(chan Event)
+	err = chunked.Watch(context.Background(), ch)
+	assert.NoError(t, err)
+
+	value := []byte("abcdefghijklmnopqrstuvwxyz")
+	kv, err := chunked.Put(context.Background(), "foo", value)
+	assert.NoError(t, err)
+	assert.Equal(t, value, kv.Value)
+
+	event := <-ch
+	assert.Equal(t, EventInsert, event.Type)
+	assert.Equal(t, "foo", event.Entry.Key)
+	assert.Equal(t, value, event.Entry.Value)
+
+	kv, err = chunked.Get(context.Background(), "foo")
+	assert.NoError(t, err)
+	assert.Equal(t, value, kv.Value)
+
+	size, err := chunked.Len(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, size)
+
+	size, err = raw.Len(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 5, size)
+
+	kv, err = chunked.Put(context.Background(), "bar", []byte("baz"))
+	assert.NoError(t, err)
+	assert.Equal(t, "baz", string(kv.Value))
+
+	event = <-ch
+	assert.Equal(t, EventInsert, event.Type)
+	assert.Equal(t, "bar", event.Entry.Key)
+
+	entries := make(chan Entry)
+	err = chunked.Entries(context.Background(), entries)
+	assert.NoError(t, err)
+	values := make(map[string]string)
+	for entry := range entries {
+		values[entry.Key] = string(entry.Value)
+	}
+	assert.Equal(t, map[string]string{"foo": string(value), "bar": "baz"}, values)
+
+	kv, err = chunked.Put(context.Background(), "foo", []byte("short"))
+	assert.NoError(t, err)
+	assert.Equal(t, "short", string(kv.Value))
+
+	event = <-ch
+	assert.Equal(t, EventUpdate, event.Type)
+	assert.Equal(t, "short", string(event.Entry.Value))
+
+	size, err = raw.Len(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, size)
+
+	_, err = chunked.Put(context.Background(), "foo", value)
+	assert.NoError(t, err)
+
+	kv, err = chunked.Remove(context.Background(), "foo")
+	assert.NoError(t, err)
+	assert.Equal(t, value, kv.Value)
+
+	size, err = raw.Len(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, size)
+
+	// Concurrent writers of the same key must leave exactly one complete value and no orphaned chunks,
+	// even when they pass options of their own
+	values = make(map[string]string)
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 5; i++ {
+		value := strings.Repeat(strconv.Itoa(i), 20+i)
+		values[value] = value
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := chunked.Put(context.Background(), "baz", []byte(value), WithTTL(time.Hour))
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	kv, err = chunked.Get(context.Background(), "baz")
+	assert.NoError(t, err)
+	assert.Contains(t, values, string(kv.Value))
+
+	chunks := (len(kv.Value) + 7) / 8
+	size, err = raw.Len(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2+chunks, size)
+
+	// Orphaned chunks are removed once they age out
+	_, err = raw.Put(context.Background(), getChunkKey(newWriteID(), 0), []byte("orphan"))
+	assert.NoError(t, err)
+	count, err := CleanupChunks(context.Background(), chunked, time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+	count, err = CleanupChunks(context.Background(), chunked, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	size, err = raw.Len(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2+chunks, size)
+
+	// Chunks are not removed and sizes are not reported when the entries can't be read
+	_, err = raw.Put(context.Background(), getChunkKey(newWriteID(), 0), []byte("orphan"))
+	assert.NoError(t, err)
+	client := chunked.(*chunkedMap).client
+	chunked.(*chunkedMap).client = &failingEntriesClient{MapServiceClient: client, after: 1}
+	_, err = CleanupChunks(context.Background(), chunked, 0)
+	assert.True(t, errors.IsUnavailable(err))
+	_, err = chunked.Len(context.Background())
+	assert.True(t, errors.IsUnavailable(err))
+	chunked.(*chunkedMap).client = client
+
+	size, err = raw.Len(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 3+chunks, size)
+
+	_, err = CleanupChunks(context.Background(), raw, 0)
+	assert.True(t, errors.IsNotSupported(err))
+
+	assert.NoError(t, test.Stop())
+}
+
+// failingEntriesClient fails entries streams after the given number of entries
+type failingEntriesClient struct {
+	api.MapServiceClient
+	after int
+}
+
+func (c *failingEntriesClient) Entries(ctx context.Context, request *api.EntriesRequest, opts ...grpc.CallOption) (api.MapService_EntriesClient, error) {
+	stream, err := c.MapServiceClient.Entries(ctx, request, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &failingEntriesStream{MapService_EntriesClient: stream, remaining: c.after}, nil
+}
+
+type failingEntriesStream struct {
+	api.MapService_EntriesClient
+	remaining int
+}
+
+func (s *failingEntriesStream) Recv() (*api.EntriesResponse, error) {
+	if s.remaining == 0 {
+		return nil, errors.Proto(errors.NewUnavailable("stream failed"))
+	}
+	s.remaining--
+	return s.MapService_EntriesClient.Recv()
+}
+
+func TestMapTTL(t *testing.T) {
+	logging.SetLevel(logging.DebugLevel)
+
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestMapTTL",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	_map, err := New(context.TODO(), "TestMapTTL", conn)
+	assert.NoError(t, err)
+
+	ch := make(chan Event)
+	err = _map.Watch(context.Background(), ch)
+	assert.NoError(t, err)
+
+	kv, err := _map.Put(context.Background(), "foo", []byte("bar"), WithTTL(100*time.Millisecond))
+	assert.NoError(t, err)
+	assert.Equal(t, 100*time.Millisecond, kv.TTL)
+
+	event := <-ch
+	assert.Equal(t, EventInsert, event.Type)
+	assert.Equal(t, 100*time.Millisecond, event.Entry.TTL)
+
+	kv, err = _map.Get(context.Background(), "foo")
+	assert.NoError(t, err)
+	assert.True(t, kv.TTL > 0)
+
+	kv, err = _map.Put(context.Background(), "bar", []byte("baz"), WithTTL(time.Hour))
+	assert.NoError(t, err)
+
+	event = <-ch
+	assert.Equal(t, EventInsert, event.Type)
+	assert.Equal(t, "bar", event.Entry.Key)
+
+	kv, err = _map.Remove(context.Background(), "bar")
+	assert.NoError(t, err)
+
+	event = <-ch
+	assert.Equal(t, EventRemove, event.Type)
+	assert.Equal(t, "bar", event.Entry.Key)
+
+	// The state machine clock is advanced by writes, so write another key once the TTL has passed
+	time.Sleep(200 * time.Millisecond)
+	kv, err = _map.Put(context.Background(), "baz", []byte("baz"))
+	assert.NoError(t, err)
+	assert.Equal(t, time.Duration(0), kv.TTL)
+
+	event = <-ch
+	assert.Equal(t, EventExpire, event.Type)
+	assert.Equal(t, "foo", event.Entry.Key)
+	assert.Equal(t, "bar", string(event.Entry.Value))
+
+	event = <-ch
+	assert.Equal(t, EventInsert, event.Type)
+	assert.Equal(t, "baz", event.Entry.Key)
+
+	_, err = _map.Get(context.Background(), "foo")
+	assert.True(t, errors.IsNotFound(err))
+
+	assert.NoError(t, test.Stop())
+}
+
+func TestMapBatch(t *testing.T) {
+	logging.SetLevel(logging.DebugLevel)
+
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestMapBatch",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	_map, err := New(context.TODO(), "TestMapBatch", conn)
+	assert.NoError(t, err)
+
+	entries := make([]Entry, 100)
+	keys := make([]string, 100)
+	for i := 0; i < 100; i++ {
+		keys[i] = fmt.Sprintf("key-%d", i)
+		entries[i] = Entry{Key: keys[i], Value: []byte(strconv.Itoa(i))}
+	}
+
+	results, err := _map.PutAll(context.Background(), entries, WithConcurrency(4))
+	assert.NoError(t, err)
+	assert.Len(t, results, 100)
+	for i, result := range results {
+		assert.NoError(t, result.Error)
+		assert.Equal(t, keys[i], result.Key)
+		assert.Equal(t, strconv.Itoa(i), string(result.Entry.Value))
+	}
+
+	size, err := _map.Len(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 100, size)
+
+	results, err = _map.GetAll(context.Background(), []string{"key-1", "none", "key-2"})
+	assert.NoError(t, err)
+	assert.Len(t, results, 3)
+	assert.Equal(t, "1", string(results[0].Entry.Value))
+	assert.True(t, errors.IsNotFound(results[1].Error))
+	assert.Equal(t, "2", string(results[2].Entry.Value))
+
+	// Non-atomic batches apply the writes that succeed
+	stale := *results[0].Entry
+	_, err = _map.Put(context.Background(), "key-1", []byte("updated"))
+	assert.NoError(t, err)
+	results, err = _map.PutAll(context.Background(), []Entry{
+		{Key: "key-3", Value: []byte("foo")},
+		stale,
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, results[0].Error)
+	assert.True(t, errors.IsConflict(results[1].Error))
+
+	// Atomic batches are rolled back on any conflict
+	results, err = _map.PutAll(context.Background(), []Entry{
+		{Key: "key-4", Value: []byte("foo")},
+		{Key: "new", Value: []byte("bar")},
+		stale,
+	}, WithAtomic())
+	assert.Error(t, err)
+	assert.True(t, errors.IsConflict(err))
+	assert.Contains(t, err.Error(), "key-1")
+
+	kv, err := _map.Get(context.Background(), "key-4")
+	assert.NoError(t, err)
+	assert.Equal(t, "4", string(kv.Value))
+	_, err = _map.Get(context.Background(), "new")
+	assert.True(t, errors.IsNotFound(err))
+
+	current, err := _map.Get(context.Background(), "key-1")
+	assert.NoError(t, err)
+	results, err = _map.PutAll(context.Background(), []Entry{
+		{Key: "key-4", Value: []byte("foo")},
+		{Key: "new", Value: []byte("bar")},
+		{ObjectMeta: current.ObjectMeta, Key: "key-1", Value: []byte("baz")},
+	}, WithAtomic())
+	assert.NoError(t, err)
+	for _, result := range results {
+		assert.NoError(t, result.Error)
+	}
+
+	_, err = _map.RemoveAll(context.Background(), []string{"key-5", "none"}, WithAtomic())
+	assert.True(t, errors.IsNotFound(err))
+	_, err = _map.Get(context.Background(), "key-5")
+	assert.NoError(t, err)
+
+	// Removed entries are restored with their TTL when a batch is rolled back
+	expiring, err := _map.Put(context.Background(), "expiring", []byte("foo"), WithTTL(time.Hour))
+	assert.NoError(t, err)
+	results, err = applyBatchWrites(context.Background(), _map, []batchWrite{
+		{key: "expiring", remove: true, prev: expiring},
+		{key: "key-1", remove: true, prev: &stale},
+	}, newBatchOptions())
+	assert.True(t, errors.IsConflict(err))
+	assert.NoError(t, results[0].Error)
+	assert.NoError(t, results[0].RollbackError)
+	kv, err = _map.Get(context.Background(), "expiring")
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", string(kv.Value))
+	assert.True(t, kv.TTL > 0)
+	_, err = _map.Remove(context.Background(), "expiring")
+	assert.NoError(t, err)
+
+	results, err = _map.RemoveAll(context.Background(), keys)
+	assert.NoError(t, err)
+	assert.Len(t, results, 100)
+	for _, result := range results {
+		assert.NoError(t, result.Error)
+	}
+
+	size, err = _map.Len(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, size)
+
+	assert.NoError(t, test.Stop())
+}
+
+func TestMapScan(t *testing.T) {
+	logging.SetLevel(logging.DebugLevel)
+
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestMapScan",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	_map, err := New(context.TODO(), "TestMapScan", conn)
+	assert.NoError(t, err)
+
+	for i := 1; i <= 5; i++ {
+		_, err = _map.Put(context.Background(), fmt.Sprintf("session/%d", i), []byte(strconv.Itoa(i)))
+		assert.NoError(t, err)
+	}
+	for i := 1; i <= 3; i++ {
+		_, err = _map.Put(context.Background(), fmt.Sprintf("user/%d", i), []byte(strconv.Itoa(i)))
+		assert.NoError(t, err)
+	}
+
+	list := func(opts ...EntriesOption) []Entry {
+		ch := make(chan Entry)
+		assert.NoError(t, _map.Entries(context.Background(), ch, opts...))
+		entries := make([]Entry, 0)
+		for entry := range ch {
+			entries = append(entries, entry)
+		}
+		return entries
+	}
+
+	assert.Len(t, list(), 8)
+	assert.Len(t, list(WithPrefix("session/")), 5)
+	assert.Len(t, list(WithPrefix("user/")), 3)
+	assert.Len(t, list(WithRange("session/2", "session/4")), 2)
+	assert.Len(t, list(WithRange("session/4", "")), 5)
+
+	keys := make([]string, 0)
+	var token string
+	for {
+		opts := []EntriesOption{WithPrefix("session/"), WithLimit(2)}
+		if token != "" {
+			opts = append(opts, WithContinuation(token))
+		}
+		page := list(opts...)
+		if len(page) == 0 {
+			break
+		}
+		assert.True(t, len(page) <= 2)
+		for _, entry := range page {
+			keys = append(keys, entry.Key)
+		}
+		token = ContinuationToken(page[len(page)-1])
+	}
+	assert.Equal(t, []string{"session/1", "session/2", "session/3", "session/4", "session/5"}, keys)
+
+	keyCh := make(chan string)
+	err = _map.Keys(context.Background(), keyCh, WithPrefix("user/"), WithLimit(2))
+	assert.NoError(t, err)
+	keys = make([]string, 0)
+	for key := range keyCh {
+		keys = append(keys, key)
+	}
+	assert.Equal(t, []string{"user/1", "user/2"}, keys)
+
+	_, err = _map.Iterate(context.Background(), WithContinuation("not a token!"))
+	assert.True(t, errors.IsInvalid(err))
+	err = _map.Keys(context.Background(), keyCh, WithContinuation("not a token!"))
+	assert.True(t, errors.IsInvalid(err))
+
+	assert.NoError(t, test.Stop())
+}
+
+func TestMapWatchFilter(t *testing.T) {
+	logging.SetLevel(logging.DebugLevel)
+
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestMapWatchFilter",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	_map, err := New(context.TODO(), "TestMapWatchFilter", conn)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	prefixCh := make(chan Event)
+	err = _map.Watch(ctx, prefixCh, WithFilter(Filter{
+		Keys:     []string{"foo"},
+		Prefixes: []string{"session/"},
+	}))
+	assert.NoError(t, err)
+
+	typeCh := make(chan Event)
+	err = _map.Watch(ctx, typeCh, WithFilter(Filter{
+		Types: []EventType{EventRemove},
+	}))
+	assert.NoError(t, err)
+
+	_, err = _map.Put(context.Background(), "bar", []byte("bar"))
+	assert.NoError(t, err)
+	_, err = _map.Put(context.Background(), "foo", []byte("foo"))
+	assert.NoError(t, err)
+	_, err = _map.Put(context.Background(), "session/1", []byte("1"))
+	assert.NoError(t, err)
+	_, err = _map.Remove(context.Background(), "bar")
+	assert.NoError(t, err)
+	_, err = _map.Remove(context.Background(), "session/1")
+	assert.NoError(t, err)
+
+	event := <-prefixCh
+	assert.Equal(t, EventInsert, event.Type)
+	assert.Equal(t, "foo", event.Entry.Key)
+	event = <-prefixCh
+	assert.Equal(t, EventInsert, event.Type)
+	assert.Equal(t, "session/1", event.Entry.Key)
+	event = <-prefixCh
+	assert.Equal(t, EventRemove, event.Type)
+	assert.Equal(t, "session/1", event.Entry.Key)
+
+	event = <-typeCh
+	assert.Equal(t, EventRemove, event.Type)
+	assert.Equal(t, "bar", event.Entry.Key)
+	event = <-typeCh
+	assert.Equal(t, EventRemove, event.Type)
+	assert.Equal(t, "session/1", event.Entry.Key)
+
+	assert.NoError(t, test.Stop())
+}
+
+func TestMapCompute(t *testing.T) {
+	logging.SetLevel(logging.DebugLevel)
+
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestMapCompute",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	_map, err := New(context.TODO(), "TestMapCompute", conn)
+	assert.NoError(t, err)
+
+	increment := func(entry *Entry) ([]byte, error) {
+		if entry == nil {
+			return []byte("1"), nil
+		}
+		i, err := strconv.Atoi(string(entry.Value))
+		if err != nil {
+			return nil, err
+		}
+		return []byte(strconv.Itoa(i + 1)), nil
+	}
+
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := _map.Compute(context.Background(), "counter", increment, WithBackoff(time.Millisecond, 10*time.Millisecond))
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	entry, err := _map.Get(context.Background(), "counter")
+	assert.NoError(t, err)
+	assert.Equal(t, "10", string(entry.Value))
+
+	entry, err = _map.Compute(context.Background(), "counter", func(entry *Entry) ([]byte, error) {
+		return nil, nil
+	})
+	assert.NoError(t, err)
+	assert.Nil(t, entry)
+	_, err = _map.Get(context.Background(), "counter")
+	assert.True(t, errors.IsNotFound(err))
+
+	entry, err = _map.ComputeIfPresent(context.Background(), "foo", increment)
+	assert.True(t, errors.IsNotFound(err))
+	assert.Nil(t, entry)
+
+	entry, err = _map.ComputeIfAbsent(context.Background(), "foo", func(key string) ([]byte, error) {
+		return []byte(key), nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", string(entry.Value))
+
+	calls := 0
+	entry, err = _map.ComputeIfAbsent(context.Background(), "foo", func(key string) ([]byte, error) {
+		calls++
+		return []byte("bar"), nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", string(entry.Value))
+	assert.Equal(t, 0, calls)
+
+	concat := func(current []byte, value []byte) ([]byte, error) {
+		return append(append([]byte{}, current...), value...), nil
+	}
+	entry, err = _map.Merge(context.Background(), "bar", []byte("a"), concat)
+	assert.NoError(t, err)
+	assert.Equal(t, "a", string(entry.Value))
+	entry, err = _map.Merge(context.Background(), "bar", []byte("b"), concat)
+	assert.NoError(t, err)
+	assert.Equal(t, "ab", string(entry.Value))
+
+	attempts := 0
+	_, err = _map.ComputeIfPresent(context.Background(), "bar", func(entry *Entry) ([]byte, error) {
+		attempts++
+		_, err := _map.Put(context.Background(), "bar", []byte(strconv.Itoa(attempts)))
+		assert.NoError(t, err)
+		return []byte("c"), nil
+	}, WithMaxRetries(2), WithBackoff(time.Millisecond, time.Millisecond))
+	assert.True(t, errors.IsConflict(err))
+	assert.Equal(t, 3, attempts)
+
+	assert.NoError(t, test.Stop())
+}
+
+func TestMapTransaction(t *testing.T) {
+	logging.SetLevel(logging.DebugLevel)
+
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestMapTransaction",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	_map, err := New(context.TODO(), "TestMapTransaction", conn)
+	assert.NoError(t, err)
+
+	foo, err := _map.Put(context.Background(), "foo", []byte("foo"))
+	assert.NoError(t, err)
+
+	// Move foo to bar
+	results, err := _map.Txn().
+		If("foo", Version(foo.Revision)).
+		If("bar", 0).
+		Put("bar", foo.Value).
+		Remove("foo").
+		Commit(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+	assert.Equal(t, "bar", results[0].Key)
+	assert.Equal(t, "foo", string(results[0].Entry.Value))
+	assert.Equal(t, "foo", results[1].Key)
+
+	_, err = _map.Get(context.Background(), "foo")
+	assert.True(t, errors.IsNotFound(err))
+	bar, err := _map.Get(context.Background(), "bar")
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", string(bar.Value))
+
+	// A failed precondition names the key and applies no writes
+	_, err = _map.Txn().
+		If("bar", Version(foo.Revision)).
+		Put("baz", []byte("baz")).
+		Commit(context.Background())
+	assert.True(t, errors.IsConflict(err))
+	assert.Contains(t, err.Error(), "bar")
+	_, err = _map.Get(context.Background(), "baz")
+	assert.True(t, errors.IsNotFound(err))
+
+	_, err = _map.Txn().
+		If("bar", 0).
+		Put("baz", []byte("baz")).
+		Commit(context.Background())
+	assert.True(t, errors.IsConflict(err))
+
+	// Unconditional writes are applied conditional on the entries read at commit
+	results, err = _map.Txn().
+		Put("bar", []byte("bar")).
+		Put("baz", []byte("baz")).
+		Remove("qux").
+		Commit(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, results, 3)
+	assert.Nil(t, results[2].Entry)
+
+	size, err := _map.Len(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, size)
+
+	// Writes that cannot be rolled back are returned to the caller
+	failing := &failingMap{Map: _map, failPut: "bar", failRemove: "new"}
+	results, err = newTransaction(failing).
+		Put("new", []byte("new")).
+		Put("bar", []byte("baz")).
+		Commit(context.Background())
+	assert.True(t, errors.IsConflict(err))
+	assert.Contains(t, err.Error(), "new")
+	assert.Len(t, results, 2)
+	assert.NoError(t, results[0].Error)
+	assert.True(t, errors.IsUnavailable(results[0].RollbackError))
+	assert.True(t, errors.IsConflict(results[1].Error))
+	_, err = _map.Get(context.Background(), "new")
+	assert.NoError(t, err)
+
+	assert.NoError(t, test.Stop())
+}
+
+// failingMap is a Map that fails writes to the given keys
+type failingMap struct {
+	Map
+	failPut    string
+	failRemove string
+}
+
+func (m *failingMap) Put(ctx context.Context, key string, value []byte, opts ...PutOption) (*Entry, error) {
+	if key == m.failPut {
+		return nil, errors.NewConflict("failed to put key '%s'", key)
+	}
+	return m.Map.Put(ctx, key, value, opts...)
+}
+
+func (m *failingMap) Remove(ctx context.Context, key string, opts ...RemoveOption) (*Entry, error) {
+	if key == m.failRemove {
+		return nil, errors.NewUnavailable("failed to remove key '%s'", key)
+	}
+	return m.Map.Remove(ctx, key, opts...)
+}
+
+func TestMapIterator(t *testing.T) {
+	logging.SetLevel(logging.DebugLevel)
+
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestMapIterator",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	_map, err := New(context.TODO(), "TestMapIterator", conn)
+	assert.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		_, err = _map.Put(context.Background(), strconv.Itoa(i), []byte(strconv.Itoa(i)))
+		assert.NoError(t, err)
+	}
+
+	iterator, err := _map.Iterate(context.Background())
+	assert.NoError(t, err)
+	elements := make([]string, 0)
+	for {
+		entry, err := iterator.Next(context.Background())
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		elements = append(elements, entry.Key)
+	}
+	assert.ElementsMatch(t, []string{"0", "1", "2"}, elements)
+	_, err = iterator.Next(context.Background())
+	assert.Equal(t, io.EOF, err)
+	iterator.Close()
+
+	iterator, err = _map.Iterate(context.Background())
+	assert.NoError(t, err)
+	_, err = iterator.Next(context.Background())
+	assert.NoError(t, err)
+	iterator.Close()
+	_, err = iterator.Next(context.Background())
+	assert.True(t, errors.IsCanceled(err))
+
+	iterator, err = _map.Iterate(context.Background())
+	assert.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = iterator.Next(ctx)
+	assert.True(t, errors.IsCanceled(err))
+	_, err = iterator.Next(context.Background())
+	assert.True(t, errors.IsCanceled(err))
+	iterator.Close()
+
+	assert.NoError(t, test.Stop())
+}
+
+func TestMapWatchBuffer(t *testing.T) {
+	logging.SetLevel(logging.DebugLevel)
+
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestMapWatchBuffer",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	_map, err := New(context.TODO(), "TestMapWatchBuffer", conn)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := make(chan Event)
+	buffer := WithBuffer(2, primitive.OverflowDropOldest)
+	err = _map.Watch(ctx, ch, buffer)
+	assert.NoError(t, err)
+
+	// A watch that is never read must not stall the map or leak once cancelled
+	blockedCtx, blockedCancel := context.WithCancel(context.Background())
+	blockedCh := make(chan Event)
+	err = _map.Watch(blockedCtx, blockedCh)
+	assert.NoError(t, err)
+
+	for i := 1; i <= 5; i++ {
+		_, err = _map.Put(context.Background(), strconv.Itoa(i), []byte(strconv.Itoa(i)))
+		assert.NoError(t, err)
+	}
+
+	// One event may be held by the sender while the buffer holds the two most recent events
+	assert.Eventually(t, func() bool {
+		return buffer.Dropped() >= 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	keys := make([]string, 0)
+	for len(keys) < 5-int(buffer.Dropped()) {
+		event := <-ch
+		keys = append(keys, event.Entry.Key)
+	}
+	assert.Equal(t, []string{"4", "5"}, keys[len(keys)-2:])
+
+	blockedCancel()
+	for range blockedCh {
+	}
+
+	cancel()
+	for range ch {
+	}
+
+	assert.NoError(t, test.Stop())
+}
+
+func TestMapWatchResync(t *testing.T) {
+	logging.SetLevel(logging.DebugLevel)
+
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestMapWatchResync",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	m, err := New(context.TODO(), "TestMapWatchResync", conn)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := make(chan Event)
+	err = m.Watch(ctx, ch, WithAutoReconnect())
+	assert.NoError(t, err)
+
+	foo, err := m.Put(context.Background(), "foo", []byte("foo"))
+	assert.NoError(t, err)
+	event := <-ch
+	assert.Equal(t, EventInsert, event.Type)
+	bar, err := m.Put(context.Background(), "bar", []byte("bar"))
+	assert.NoError(t, err)
+	event = <-ch
+	assert.Equal(t, EventInsert, event.Type)
+
+	// Simulate changes missed while a watch stream was down
+	tracker := newRevisionTracker()
+	tracker.update(Event{Type: EventInsert, Entry: *foo})
+	tracker.update(Event{Type: EventInsert, Entry: *bar})
+	foo, err = m.Put(context.Background(), "foo", []byte("baz"))
+	assert.NoError(t, err)
+	_, err = m.Remove(context.Background(), "bar")
+	assert.NoError(t, err)
+	baz, err := m.Put(context.Background(), "baz", []byte("baz"))
+	assert.NoError(t, err)
+
+	events := make(map[string]Event)
+	stream, streamCancel, err := m.(*_map).resync(context.Background(), &api.EventsRequest{Headers: m.(*_map).GetHeaders()}, tracker, func(event Event) bool {
+		events[event.Entry.Key] = event
+		return true
+	})
+	assert.NoError(t, err)
+	assert.NotNil(t, stream)
+	defer streamCancel()
+
+	assert.Len(t, events, 4)
+	assert.Equal(t, EventResync, events[""].Type)
+	assert.Equal(t, EventUpdate, events["foo"].Type)
+	assert.Equal(t, "baz", string(events["foo"].Entry.Value))
+	assert.Equal(t, EventRemove, events["bar"].Type)
+	assert.Equal(t, EventInsert, events["baz"].Type)
+
+	// Events reflected by the resynchronized state are discarded
+	assert.False(t, tracker.update(Event{Type: EventUpdate, Entry: *foo}))
+	assert.False(t, tracker.update(Event{Type: EventInsert, Entry: *baz}))
+	assert.False(t, tracker.update(Event{Type: EventRemove, Entry: Entry{Key: "bar"}}))
+	assert.True(t, tracker.update(Event{Type: EventRemove, Entry: *baz}))
+
+	// Changes made before the stream breaks are delivered by the stream
+	for i := 0; i < 3; i++ {
+		<-ch
+	}
+
+	// A broken stream is re-established and signaled with a resync event
+	test.BreakStreams()
+	event = <-ch
+	assert.Equal(t, EventResync, event.Type)
+
+	_, err = m.Put(context.Background(), "qux", []byte("qux"))
+	assert.NoError(t, err)
+	event = <-ch
+	assert.Equal(t, EventInsert, event.Type)
+	assert.Equal(t, "qux", event.Entry.Key)
+
+	assert.NoError(t, test.Stop())
+}
+
+func TestMapWatchSynced(t *testing.T) {
+	logging.SetLevel(logging.DebugLevel)
+
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestMapWatchSynced",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	m, err := New(context.TODO(), "TestMapWatchSynced", conn)
+	assert.NoError(t, err)
+
+	_, err = m.Put(context.Background(), "foo", []byte("foo"))
+	assert.NoError(t, err)
+	_, err = m.Put(context.Background(), "bar", []byte("bar"))
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := make(chan Event)
+	err = m.Watch(ctx, ch, WithReplay())
+	assert.NoError(t, err)
+
+	event := <-ch
+	assert.Equal(t, EventReplay, event.Type)
+	assert.Contains(t, []string{"foo", "bar"}, event.Entry.Key)
+	event = <-ch
+	assert.Equal(t, EventReplay, event.Type)
+	assert.Contains(t, []string{"foo", "bar"}, event.Entry.Key)
+	event = <-ch
+	assert.Equal(t, EventSynced, event.Type)
+
+	_, err = m.Put(context.Background(), "baz", []byte("baz"))
+	assert.NoError(t, err)
+	event = <-ch
+	assert.Equal(t, EventInsert, event.Type)
+	assert.Equal(t, "baz", event.Entry.Key)
+
+	// The synced event is delivered regardless of filters
+	filtered := make(chan Event)
+	err = m.Watch(ctx, filtered, WithReplay(), WithFilter(Filter{Key: "foo"}))
+	assert.NoError(t, err)
+
+	event = <-filtered
+	assert.Equal(t, EventReplay, event.Type)
+	assert.Equal(t, "foo", event.Entry.Key)
+	event = <-filtered
+	assert.Equal(t, EventSynced, event.Type)
+
+	// Changes made before the replay are not delivered after the synced event
+	tracker := newRevisionTracker()
+	err = m.(*_map).replay(context.Background(), "", tracker, func(event Event) bool {
+		return true
+	})
+	assert.NoError(t, err)
+	foo, err := m.Get(context.Background(), "foo")
+	assert.NoError(t, err)
+	assert.False(t, tracker.update(Event{Type: EventInsert, Entry: *foo}))
+	assert.False(t, tracker.update(Event{Type: EventRemove, Entry: Entry{Key: "qux"}}))
+	assert.True(t, tracker.update(Event{Type: EventRemove, Entry: *foo}))
+
+	assert.NoError(t, test.Stop())
+}
+
+func TestMapWatchPrevValues(t *testing.T) {
+	logging.SetLevel(logging.DebugLevel)
+
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestMapWatchPrevValues",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	m, err := New(context.TODO(), "TestMapWatchPrevValues", conn)
+	assert.NoError(t, err)
+
+	_, err = m.Put(context.Background(), "foo", []byte("foo"))
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := make(chan Event)
+	err = m.Watch(ctx, ch, WithPrevValues())
+	assert.NoError(t, err)
+
+	_, err = m.Put(context.Background(), "foo", []byte("bar"))
+	assert.NoError(t, err)
+	event := <-ch
+	assert.Equal(t, EventUpdate, event.Type)
+	assert.Equal(t, "bar", string(event.Entry.Value))
+	assert.NotNil(t, event.PrevEntry)
+	assert.Equal(t, "foo", string(event.PrevEntry.Value))
+
+	_, err = m.Put(context.Background(), "bar", []byte("bar"))
+	assert.NoError(t, err)
+	event = <-ch
+	assert.Equal(t, EventInsert, event.Type)
+	assert.Nil(t, event.PrevEntry)
+
+	_, err = m.Remove(context.Background(), "foo")
+	assert.NoError(t, err)
+	event = <-ch
+	assert.Equal(t, EventRemove, event.Type)
+	assert.NotNil(t, event.PrevEntry)
+	assert.Equal(t, "bar", string(event.PrevEntry.Value))
+
+	// Events older than the tracked entries are given no previous entry
+	view := newEntryView()
+	view.update(&Event{Type: EventReplay, Entry: Entry{Key: "foo", ObjectMeta: meta.ObjectMeta{Revision: 2}}})
+	stale := Event{Type: EventUpdate, Entry: Entry{Key: "foo", ObjectMeta: meta.ObjectMeta{Revision: 1}}}
+	view.update(&stale)
+	assert.Nil(t, stale.PrevEntry)
+	update := Event{Type: EventUpdate, Entry: Entry{Key: "foo", ObjectMeta: meta.ObjectMeta{Revision: 3}}}
+	view.update(&update)
+	assert.NotNil(t, update.PrevEntry)
+	assert.Equal(t, meta.Revision(2), update.PrevEntry.Revision)
+
+	assert.NoError(t, test.Stop())
+}
+
+func TestMapConditions(t *testing.T) {
+	logging.SetLevel(logging.DebugLevel)
+
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestMapConditions",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	m, err := New(context.TODO(), "TestMapConditions", conn)
+	assert.NoError(t, err)
+
+	_, err = m.Put(context.Background(), "foo", []byte("bar"), IfExists())
+	assert.Error(t, err)
+	assert.True(t, errors.IsNotFound(err))
+
+	_, err = m.Put(context.Background(), "foo", []byte("bar"))
+	assert.NoError(t, err)
+
+	_, err = m.Put(context.Background(), "foo", []byte("baz"), IfValue([]byte("baz")))
+	assert.Error(t, err)
+	assert.True(t, errors.IsConflict(err))
+
+	entry, err := m.Put(context.Background(), "foo", []byte("baz"), IfValue([]byte("bar")))
+	assert.NoError(t, err)
+	assert.Equal(t, "baz", string(entry.Value))
+
+	entry, err = m.Put(context.Background(), "foo", []byte("qux"), IfExists())
+	assert.NoError(t, err)
+	assert.Equal(t, "qux", string(entry.Value))
+
+	_, err = m.Remove(context.Background(), "foo", IfValue([]byte("baz")))
+	assert.Error(t, err)
+	assert.True(t, errors.IsConflict(err))
+
+	entry, err = m.Remove(context.Background(), "foo", IfValue([]byte("qux")))
+	assert.NoError(t, err)
+	assert.Equal(t, "qux", string(entry.Value))
+
+	_, err = m.Remove(context.Background(), "foo", IfExists())
+	assert.Error(t, err)
+	assert.True(t, errors.IsNotFound(err))
+
+	// Conditions are checked against the reassembled values of chunked maps
+	chunked, err := New(context.TODO(), "TestMapConditions", conn, WithChunkSize(4))
+	assert.NoError(t, err)
+
+	_, err = chunked.Put(context.Background(), "bar", []byte("Hello world!"))
+	assert.NoError(t, err)
+	_, err = chunked.Put(context.Background(), "bar", []byte("Hello world again!"), IfValue([]byte("Hello world?")))
+	assert.True(t, errors.IsConflict(err))
+	entry, err = chunked.Put(context.Background(), "bar", []byte("Hello world again!"), IfValue([]byte("Hello world!")))
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello world again!", string(entry.Value))
+	entry, err = chunked.Remove(context.Background(), "bar", IfValue([]byte("Hello world again!")))
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello world again!", string(entry.Value))
+
+	assert.NoError(t, test.Stop())
+}
+
+func TestMapGetAndPut(t *testing.T) {
+	logging.SetLevel(logging.DebugLevel)
+
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestMapGetAndPut",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	m, err := New(context.TODO(), "TestMapGetAndPut", conn)
+	assert.NoError(t, err)
+
+	prev, err := m.GetAndPut(context.Background(), "foo", []byte("bar"))
+	assert.NoError(t, err)
+	assert.Nil(t, prev)
+
+	prev, err = m.GetAndPut(context.Background(), "foo", []byte("baz"))
+	assert.NoError(t, err)
+	assert.NotNil(t, prev)
+	assert.Equal(t, "bar", string(prev.Value))
+
+	entry, err := m.Get(context.Background(), "foo")
+	assert.NoError(t, err)
+	assert.Equal(t, "baz", string(entry.Value))
+	assert.NotEqual(t, prev.Revision, entry.Revision)
+
+	entry, ok, err := m.PutIfAbsent(context.Background(), "foo", []byte("qux"))
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, "baz", string(entry.Value))
+
+	entry, ok, err = m.PutIfAbsent(context.Background(), "bar", []byte("qux"))
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "qux", string(entry.Value))
+
+	// Concurrent swaps each observe a distinct previous value
+	var wg sync.WaitGroup
+	prevs := make(chan string, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			prev, err := m.GetAndPut(context.Background(), "baz", []byte(strconv.Itoa(i)))
+			assert.NoError(t, err)
+			if prev != nil {
+				prevs <- string(prev.Value)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(prevs)
+	seen := make(map[string]bool)
+	for prev := range prevs {
+		assert.False(t, seen[prev])
+		seen[prev] = true
+	}
+	assert.Len(t, seen, 9)
+
+	assert.NoError(t, test.Stop())
+}
+
+func TestCachedMap(t *testing.T) {
+	logging.SetLevel(logging.DebugLevel)
+
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestCachedMap",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn1, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	conn2, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	m, err := New(context.TODO(), "TestCachedMap", conn1)
+	assert.NoError(t, err)
+
+	remote, err := New(context.TODO(), "TestCachedMap", conn2)
+	assert.NoError(t, err)
+
+	_, err = NewCachedMap(m, WithCacheSize(0))
+	assert.True(t, errors.IsInvalid(err))
+
+	_, err = remote.Put(context.Background(), "foo", []byte("bar"))
+	assert.NoError(t, err)
+
+	cached, err := NewCachedMap(m, WithCacheSize(2))
+	assert.NoError(t, err)
+
+	// Wait for the cache watch to connect
+	for {
+		cached.(*cachedMap).mu.Lock()
+		connected := cached.(*cachedMap).connected
+		cached.(*cachedMap).mu.Unlock()
+		if connected {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	entry, err := cached.Get(context.Background(), "foo")
+	assert.NoError(t, err)
+	assert.Equal(t, "bar", string(entry.Value))
+	entry, err = cached.Get(context.Background(), "foo")
+	assert.NoError(t, err)
+	assert.Equal(t, "bar", string(entry.Value))
+	assert.Equal(t, CacheStats{Hits: 1, Misses: 1, Size: 1}, cached.Stats())
+
+	// Missing keys are cached
+	_, err = cached.Get(context.Background(), "bar")
+	assert.True(t, errors.IsNotFound(err))
+	_, err = cached.Get(context.Background(), "bar")
+	assert.True(t, errors.IsNotFound(err))
+	assert.Equal(t, CacheStats{Hits: 2, Misses: 2, Size: 2}, cached.Stats())
+
+	// Changes made by other clients are applied from the watch
+	_, err = remote.Put(context.Background(), "foo", []byte("baz"))
+	assert.NoError(t, err)
+	_, err = remote.Put(context.Background(), "bar", []byte("baz"))
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		entry, err := cached.Get(context.Background(), "bar")
+		return err == nil && string(entry.Value) == "baz"
+	}, time.Second, 10*time.Millisecond)
+	assert.Eventually(t, func() bool {
+		entry, err := cached.Get(context.Background(), "foo")
+		return err == nil && string(entry.Value) == "baz"
+	}, time.Second, 10*time.Millisecond)
+
+	// Writes through the cache are read back
+	_, err = cached.Put(context.Background(), "foo", []byte("qux"))
+	assert.NoError(t, err)
+	entry, err = cached.Get(context.Background(), "foo")
+	assert.NoError(t, err)
+	assert.Equal(t, "qux", string(entry.Value))
+
+	// Transactions committed through the cache invalidate the written keys
+	assert.Eventually(t, func() bool {
+		hits := cached.Stats().Hits
+		_, err := cached.Get(context.Background(), "foo")
+		return err == nil && cached.Stats().Hits == hits+1
+	}, time.Second, 10*time.Millisecond)
+	misses := cached.Stats().Misses
+	_, err = cached.Txn().Put("foo", []byte("txn")).Commit(context.Background())
+	assert.NoError(t, err)
+	entry, err = cached.Get(context.Background(), "foo")
+	assert.NoError(t, err)
+	assert.Equal(t, "txn", string(entry.Value))
+	assert.Equal(t, misses+1, cached.Stats().Misses)
+
+	// The least recently used key is evicted
+	for _, key := range []string{"a", "b", "c"} {
+		_, err = cached.Get(context.Background(), key)
+		assert.True(t, errors.IsNotFound(err))
+	}
+	assert.Equal(t, 2, cached.Stats().Size)
+	misses = cached.Stats().Misses
+	_, err = cached.Get(context.Background(), "a")
+	assert.True(t, errors.IsNotFound(err))
+	assert.Equal(t, misses+1, cached.Stats().Misses)
+
+	assert.NoError(t, cached.Close(context.Background()))
+	assert.NoError(t, test.Stop())
+}
+
+func TestMapReplica(t *testing.T) {
+	logging.SetLevel(logging.DebugLevel)
+
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestMapReplica",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	m, err := New(context.TODO(), "TestMapReplica", conn)
+	assert.NoError(t, err)
+
+	_, err = m.Put(context.Background(), "foo", []byte("foo"))
+	assert.NoError(t, err)
+	bar, err := m.Put(context.Background(), "bar", []byte("bar"))
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	replica, err := NewReplica(ctx, m)
+	assert.NoError(t, err)
+
+	<-replica.Ready()
+	assert.Equal(t, 2, replica.Len())
+	assert.Equal(t, bar.Revision, replica.Revision())
+	entry, ok := replica.Get("foo")
+	assert.True(t, ok)
+	assert.Equal(t, "foo", string(entry.Value))
+
+	baz, err := m.Put(context.Background(), "baz", []byte("baz"))
+	assert.NoError(t, err)
+	assert.NoError(t, replica.WaitFor(context.Background(), baz.Revision))
+	entry, ok = replica.Get("baz")
+	assert.True(t, ok)
+	assert.Equal(t, "baz", string(entry.Value))
+
+	foo, err := m.Put(context.Background(), "foo", []byte("bar"))
+	assert.NoError(t, err)
+	assert.NoError(t, replica.WaitFor(context.Background(), foo.Revision))
+
+	// Removals are waited for by the key and revision of the removed entry
+	timeout, timeoutCancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	err = replica.WaitForRemoval(timeout, "bar", bar.Revision)
+	timeoutCancel()
+	assert.True(t, errors.IsTimeout(err) || errors.IsCanceled(err))
+	removed, err := m.Remove(context.Background(), "bar")
+	assert.NoError(t, err)
+	assert.NoError(t, replica.WaitForRemoval(context.Background(), "bar", removed.Revision))
+	_, ok = replica.Get("bar")
+	assert.False(t, ok)
+	assert.Equal(t, 2, replica.Len())
+
+	keys := make([]string, 0)
+	replica.Range(func(entry Entry) bool {
+		keys = append(keys, entry.Key)
+		return true
+	})
+	assert.ElementsMatch(t, []string{"foo", "baz"}, keys)
+
+	timeout, timeoutCancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer timeoutCancel()
+	err = replica.WaitFor(timeout, foo.Revision+100)
+	assert.True(t, errors.IsTimeout(err) || errors.IsCanceled(err))
+
+	cancel()
+	<-replica.Done()
+	err = replica.WaitFor(context.Background(), foo.Revision+100)
+	assert.True(t, errors.IsUnavailable(err))
+
+	assert.NoError(t, test.Stop())
+}
+
+func TestLoadingCache(t *testing.T) {
+	logging.SetLevel(logging.DebugLevel)
+
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestLoadingCache",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	m, err := New(context.TODO(), "TestLoadingCache", conn)
+	assert.NoError(t, err)
+
+	var mu sync.Mutex
+	loads := make(map[string]int)
+	loader := func(ctx context.Context, key string) ([]byte, error) {
+		mu.Lock()
+		loads[key]++
+		mu.Unlock()
+		time.Sleep(50 * time.Millisecond)
+		if key == "missing" {
+			return nil, nil
+		}
+		return []byte("loaded-" + key), nil
+	}
+	cache := NewLoadingCache(m, loader, WithLoadTTL(time.Hour), WithNegativeTTL(time.Hour))
+
+	// Concurrent misses for the same key are loaded once
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			entry, err := cache.Get(context.Background(), "foo")
+			assert.NoError(t, err)
+			assert.Equal(t, "loaded-foo", string(entry.Value))
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, loads["foo"])
+
+	entry, err := m.Get(context.Background(), "foo")
+	assert.NoError(t, err)
+	assert.Equal(t, "loaded-foo", string(entry.Value))
+	assert.NotNil(t, entry.TTL)
+
+	// Values in the map are not loaded
+	_, err = m.Put(context.Background(), "bar", []byte("bar"))
+	assert.NoError(t, err)
+	entry, err = cache.Get(context.Background(), "bar")
+	assert.NoError(t, err)
+	assert.Equal(t, "bar", string(entry.Value))
+	assert.Equal(t, 0, loads["bar"])
+
+	// Missing values are remembered
+	_, err = cache.Get(context.Background(), "missing")
+	assert.True(t, errors.IsNotFound(err))
+	_, err = cache.Get(context.Background(), "missing")
+	assert.True(t, errors.IsNotFound(err))
+	assert.Equal(t, 1, loads["missing"])
+
+	// Invalidated keys are loaded again
+	assert.NoError(t, cache.Invalidate(context.Background(), "foo"))
+	assert.NoError(t, cache.Invalidate(context.Background(), "missing"))
+	_, err = cache.Get(context.Background(), "foo")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, loads["foo"])
+	_, err = cache.Get(context.Background(), "missing")
+	assert.True(t, errors.IsNotFound(err))
+	assert.Equal(t, 2, loads["missing"])
+
+	// A shared load continues when the reader that started it gives up
+	release := make(chan struct{})
+	loadErrs := make(chan error, 2)
+	cache = NewLoadingCache(m, func(ctx context.Context, key string) ([]byte, error) {
+		select {
+		case <-release:
+			loadErrs <- nil
+			return []byte("loaded-" + key), nil
+		case <-ctx.Done():
+			loadErrs <- ctx.Err()
+			return nil, ctx.Err()
+		}
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error)
+	go func() {
+		_, err := cache.Get(ctx, "baz")
+		firstErr <- err
+	}()
+	assert.Eventually(t, func() bool {
+		c := cache.(*loadingCache)
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return c.loads["baz"] != nil && c.loads["baz"].refs == 1
+	}, time.Second, 10*time.Millisecond)
+	secondEntry := make(chan *Entry)
+	go func() {
+		entry, err := cache.Get(context.Background(), "baz")
+		assert.NoError(t, err)
+		secondEntry <- entry
+	}()
+	assert.Eventually(t, func() bool {
+		c := cache.(*loadingCache)
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return c.loads["baz"].refs == 2
+	}, time.Second, 10*time.Millisecond)
+	cancel()
+	assert.True(t, errors.IsCanceled(<-firstErr))
+	close(release)
+	assert.NoError(t, <-loadErrs)
+	assert.Equal(t, "loaded-baz", string((<-secondEntry).Value))
+
+	// A shared load is cancelled once every reader has given up
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	cache = NewLoadingCache(m, func(ctx context.Context, key string) ([]byte, error) {
+		<-ctx.Done()
+		loadErrs <- ctx.Err()
+		return nil, ctx.Err()
+	})
+	_, err = cache.Get(ctx, "qux")
+	assert.True(t, errors.IsTimeout(err))
+	assert.Equal(t, context.Canceled, <-loadErrs)
+
+	assert.NoError(t, test.Stop())
+}
+
+func TestPageIterator(t *testing.T) {
+	newIterator := func() *entryIterator {
+		entries := make([]Entry, 0)
+		for _, i := range rand.Perm(100) {
+			entries = append(entries, Entry{Key: fmt.Sprintf("key-%03d", i)})
+		}
+		return &entryIterator{
+			next: func(ctx context.Context) (Entry, error) {
+				if len(entries) == 0 {
+					return Entry{}, io.EOF
+				}
+				entry := entries[0]
+				entries = entries[1:]
+				return entry, nil
+			},
+			close: func() {},
+		}
+	}
+
+	readKeys := func(iterator EntryIterator) []string {
+		keys := make([]string, 0)
+		for {
+			entry, err := iterator.Next(context.Background())
+			if err == io.EOF {
+				return keys
+			}
+			assert.NoError(t, err)
+			keys = append(keys, entry.Key)
+		}
+	}
+
+	keys := readKeys(pageIterator(newIterator(), newEntriesOptions(WithLimit(10))))
+	assert.Len(t, keys, 10)
+	for i, key := range keys {
+		assert.Equal(t, fmt.Sprintf("key-%03d", i), key)
+	}
+
+	// A limit larger than the map returns all the entries in key order
+	keys = readKeys(pageIterator(newIterator(), newEntriesOptions(WithLimit(200))))
+	assert.Len(t, keys, 100)
+	for i, key := range keys {
+		assert.Equal(t, fmt.Sprintf("key-%03d", i), key)
+	}
+}
@@ -15,10 +15,14 @@
 package _map //nolint:golint
 
 import (
+	"encoding/base64"
 	api "github.com/atomix/atomix-api/go/atomix/primitive/map"
 	metaapi "github.com/atomix/atomix-api/go/atomix/primitive/meta"
 	"github.com/atomix/atomix-go-client/pkg/atomix/primitive"
+	"github.com/atomix/atomix-go-framework/pkg/atomix/errors"
 	"github.com/atomix/atomix-go-framework/pkg/atomix/meta"
+	"strings"
+	"time"
 )
 
 // Option is a map option
@@ -28,7 +32,71 @@ type Option interface {
 }
 
 // newMapOptions is map options
-type newMapOptions struct{}
+type newMapOptions struct {
+	chunkSize int
+}
+
+// CacheOption is an option for a CachedMap
+type CacheOption interface {
+	applyCache(options *cacheOptions)
+}
+
+// cacheOptions is cached map options
+type cacheOptions struct {
+	size int
+}
+
+// WithCacheSize sets the maximum number of keys held by a CachedMap
+func WithCacheSize(size int) CacheOption {
+	return cacheSizeOption{size: size}
+}
+
+type cacheSizeOption struct {
+	size int
+}
+
+func (o cacheSizeOption) applyCache(options *cacheOptions) {
+	options.size = o.size
+}
+
+// LoadingCacheOption is an option for a LoadingCache
+type LoadingCacheOption interface {
+	applyLoadingCache(options *loadingCacheOptions)
+}
+
+// loadingCacheOptions is loading cache options
+type loadingCacheOptions struct {
+	ttl         time.Duration
+	negativeTTL time.Duration
+}
+
+// WithLoadTTL sets the time to live of the entries written to the map by a LoadingCache
+func WithLoadTTL(ttl time.Duration) LoadingCacheOption {
+	return loadTTLOption{ttl: ttl}
+}
+
+type loadTTLOption struct {
+	ttl time.Duration
+}
+
+func (o loadTTLOption) applyLoadingCache(options *loadingCacheOptions) {
+	options.ttl = o.ttl
+}
+
+// WithNegativeTTL sets how long a LoadingCache remembers keys for which the loader has no value
+// Negative results are remembered by the process rather than written to the map. By default, keys with no
+// value are loaded on every read.
+func WithNegativeTTL(ttl time.Duration) LoadingCacheOption {
+	return negativeTTLOption{ttl: ttl}
+}
+
+type negativeTTLOption struct {
+	ttl time.Duration
+}
+
+func (o negativeTTLOption) applyLoadingCache(options *loadingCacheOptions) {
+	options.negativeTTL = o.ttl
+}
 
 // PutOption is an option for the Put method
 type PutOption interface {
@@ -103,6 +171,26 @@ func (o NotSetOption) afterPut(response *api.PutResponse) {
 
 }
 
+// WithTTL sets the time to live of the entry
+// Once the TTL expires, the entry is removed from the map and an EventExpire event is published to watchers.
+func WithTTL(ttl time.Duration) PutOption {
+	return &TTLOption{ttl: ttl}
+}
+
+// TTLOption is a PutOption that sets the time to live of an entry
+type TTLOption struct {
+	ttl time.Duration
+}
+
+func (o TTLOption) beforePut(request *api.PutRequest) {
+	ttl := o.ttl
+	request.Entry.Value.TTL = &ttl
+}
+
+func (o TTLOption) afterPut(response *api.PutResponse) {
+
+}
+
 // GetOption is an option for the Get method
 type GetOption interface {
 	beforeGet(request *api.GetRequest)
@@ -130,25 +218,397 @@ func (o replayOption) afterWatch(response *api.EventsResponse) {
 
 }
 
+// eventFilter is implemented by watch options that filter events on the client side
+type eventFilter interface {
+	filterEvent(event Event) bool
+}
+
+// filterEvent returns a bool indicating whether the given event passes all the given filters
+func filterEvent(event Event, filters []eventFilter) bool {
+	for _, filter := range filters {
+		if !filter.filterEvent(event) {
+			return false
+		}
+	}
+	return true
+}
+
 type filterOption struct {
 	filter Filter
 }
 
 func (o filterOption) beforeWatch(request *api.EventsRequest) {
-	if o.filter.Key != "" {
+	// The server supports filtering by a single key. Other filters are applied by the client.
+	if o.filter.Key != "" && len(o.filter.Keys) == 0 && len(o.filter.Prefixes) == 0 {
 		request.Key = o.filter.Key
+	} else if o.filter.Key == "" && len(o.filter.Keys) == 1 && len(o.filter.Prefixes) == 0 {
+		request.Key = o.filter.Keys[0]
 	}
 }
 
 func (o filterOption) afterWatch(response *api.EventsResponse) {
 }
 
+func (o filterOption) filterEvent(event Event) bool {
+	return o.filter.matches(event)
+}
+
 // WithFilter returns a watch option that filters the watch events
 func WithFilter(filter Filter) WatchOption {
 	return filterOption{filter: filter}
 }
 
 // Filter is a watch filter configuration
+// If any of Key, Keys or Prefixes is set, only events for keys matching at least one of them are delivered.
+// If Types is set, only events of the given types are delivered. EventRemove matches removals caused by
+// the expiration of an entry's TTL as well as explicit removals.
 type Filter struct {
+	// Key is a key to watch
 	Key string
+
+	// Keys is a set of keys to watch
+	Keys []string
+
+	// Prefixes is a set of key prefixes to watch
+	Prefixes []string
+
+	// Types is a set of event types to watch
+	Types []EventType
+}
+
+// matches returns a bool indicating whether the given event matches the filter
+func (f Filter) matches(event Event) bool {
+	return f.matchesKey(event.Entry.Key) && f.matchesType(event.Type)
+}
+
+func (f Filter) matchesKey(key string) bool {
+	if f.Key == "" && len(f.Keys) == 0 && len(f.Prefixes) == 0 {
+		return true
+	}
+	if f.Key != "" && key == f.Key {
+		return true
+	}
+	for _, k := range f.Keys {
+		if key == k {
+			return true
+		}
+	}
+	for _, prefix := range f.Prefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f Filter) matchesType(eventType EventType) bool {
+	if len(f.Types) == 0 {
+		return true
+	}
+	for _, t := range f.Types {
+		if t == eventType || (t == EventRemove && eventType == EventExpire) {
+			return true
+		}
+	}
+	return false
+}
+
+// BatchOption is an option for the PutAll, GetAll and RemoveAll methods
+type BatchOption interface {
+	applyBatch(options *batchOptions)
+}
+
+// batchOptions is batch operation options
+type batchOptions struct {
+	concurrency int
+	atomic      bool
+}
+
+func newBatchOptions(opts ...BatchOption) batchOptions {
+	options := batchOptions{
+		concurrency: defaultBatchConcurrency,
+	}
+	for _, opt := range opts {
+		opt.applyBatch(&options)
+	}
+	return options
+}
+
+// WithConcurrency sets the maximum number of concurrent requests issued by a batch operation
+func WithConcurrency(concurrency int) BatchOption {
+	return concurrencyOption{concurrency: concurrency}
+}
+
+type concurrencyOption struct {
+	concurrency int
+}
+
+func (o concurrencyOption) applyBatch(options *batchOptions) {
+	options.concurrency = o.concurrency
+}
+
+// WithAtomic returns a batch option that applies the batch on an all-or-nothing basis
+// Each write is conditioned on the current version of its key. If any write fails, the writes that were
+// applied are rolled back and a conflict error naming the failed key is returned. Note that concurrent
+// readers may observe the batch partially applied before it's rolled back.
+func WithAtomic() BatchOption {
+	return atomicOption{}
+}
+
+type atomicOption struct{}
+
+func (o atomicOption) applyBatch(options *batchOptions) {
+	options.atomic = true
+}
+
+// EntriesOption is an option for the Entries and Keys methods
+type EntriesOption interface {
+	applyEntries(options *entriesOptions)
+}
+
+// entriesOptions is entries options
+type entriesOptions struct {
+	prefix string
+	start  string
+	end    string
+	after  string
+	limit  int
+	// err is an error to be returned when the entries are listed
+	err error
+}
+
+func newEntriesOptions(opts ...EntriesOption) entriesOptions {
+	options := entriesOptions{}
+	for _, opt := range opts {
+		opt.applyEntries(&options)
+	}
+	return options
+}
+
+// matches returns a bool indicating whether the given key matches the options
+func (o entriesOptions) matches(key string) bool {
+	if o.prefix != "" && !strings.HasPrefix(key, o.prefix) {
+		return false
+	}
+	if o.start != "" && key < o.start {
+		return false
+	}
+	if o.end != "" && key >= o.end {
+		return false
+	}
+	if o.after != "" && key <= o.after {
+		return false
+	}
+	return true
+}
+
+// ordered returns a bool indicating whether the entries must be returned in key order
+func (o entriesOptions) ordered() bool {
+	return o.limit > 0 || o.after != ""
+}
+
+// WithPrefix returns an entries option that lists only keys with the given prefix
+func WithPrefix(prefix string) EntriesOption {
+	return prefixOption{prefix: prefix}
+}
+
+type prefixOption struct {
+	prefix string
+}
+
+func (o prefixOption) applyEntries(options *entriesOptions) {
+	options.prefix = o.prefix
+}
+
+// WithRange returns an entries option that lists only keys in the lexical range [start, end)
+// An empty start or end leaves the range unbounded on that side.
+func WithRange(start, end string) EntriesOption {
+	return rangeOption{start: start, end: end}
+}
+
+type rangeOption struct {
+	start string
+	end   string
+}
+
+func (o rangeOption) applyEntries(options *entriesOptions) {
+	options.start = o.start
+	options.end = o.end
+}
+
+// WithLimit returns an entries option that lists at most the given number of entries
+// When a limit is set, entries are listed in key order. Ordering is done by the client: every page streams
+// all the entries in the map, keeping only the entries of the page in memory, and Keys still transfers the
+// entry values.
+func WithLimit(limit int) EntriesOption {
+	return limitOption{limit: limit}
+}
+
+type limitOption struct {
+	limit int
+}
+
+func (o limitOption) applyEntries(options *entriesOptions) {
+	options.limit = o.limit
+}
+
+// WithContinuation returns an entries option that continues listing after the page ending with the given token
+// Continuation tokens are obtained from the last entry of a page by calling ContinuationToken. When a
+// continuation token is set, entries are listed in key order. Each page is read from the map as it is when
+// the page is requested, so keys written between pages may be skipped or included. An invalid token fails
+// the listing with an Invalid error.
+func WithContinuation(token string) EntriesOption {
+	return continuationOption{token: token}
+}
+
+type continuationOption struct {
+	token string
+}
+
+func (o continuationOption) applyEntries(options *entriesOptions) {
+	key, err := base64.RawURLEncoding.DecodeString(o.token)
+	if err != nil {
+		options.err = errors.NewInvalid("invalid continuation token '%s'", o.token)
+		return
+	}
+	options.after = string(key)
+}
+
+// ContinuationToken returns a token with which to continue listing entries after the given entry
+// Like WithLimit, every page streams all the entries in the map.
+func ContinuationToken(entry Entry) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(entry.Key))
+}
+
+// ComputeOption is an option for the Compute, ComputeIfAbsent, ComputeIfPresent and Merge methods
+type ComputeOption interface {
+	applyCompute(options *computeOptions)
+}
+
+// computeOptions is compute options
+type computeOptions struct {
+	maxRetries     int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+}
+
+func newComputeOptions(opts ...ComputeOption) computeOptions {
+	options := computeOptions{
+		maxRetries:     -1,
+		initialBackoff: defaultInitialBackoff,
+		maxBackoff:     defaultMaxBackoff,
+	}
+	for _, opt := range opts {
+		opt.applyCompute(&options)
+	}
+	if options.initialBackoff <= 0 {
+		options.initialBackoff = defaultInitialBackoff
+	}
+	if options.maxBackoff < options.initialBackoff {
+		options.maxBackoff = options.initialBackoff
+	}
+	return options
+}
+
+// WithMaxRetries sets the maximum number of times an update is retried when the key is modified concurrently
+// If the update still conflicts after the last retry, a conflict error is returned. By default, updates are
+// retried until the context is done.
+func WithMaxRetries(retries int) ComputeOption {
+	return maxRetriesOption{retries: retries}
+}
+
+type maxRetriesOption struct {
+	retries int
+}
+
+func (o maxRetriesOption) applyCompute(options *computeOptions) {
+	options.maxRetries = o.retries
+}
+
+// WithBackoff sets the initial and maximum delay between retries of a conflicting update
+// The delay is doubled after each retry up to the maximum.
+func WithBackoff(initial, max time.Duration) ComputeOption {
+	return backoffOption{initial: initial, max: max}
+}
+
+type backoffOption struct {
+	initial time.Duration
+	max     time.Duration
+}
+
+func (o backoffOption) applyCompute(options *computeOptions) {
+	options.initialBackoff = o.initial
+	options.maxBackoff = o.max
+}
+
+// WithBuffer returns a watch option that buffers events between the watch stream and the channel
+// See primitive.WatchBuffer.
+func WithBuffer(size int, policy primitive.OverflowPolicy) *BufferOption {
+	return &BufferOption{
+		WatchBuffer: primitive.NewWatchBuffer(size, policy),
+	}
+}
+
+// BufferOption is a watch option that configures the buffering of watch events
+type BufferOption struct {
+	*primitive.WatchBuffer
+}
+
+func (o *BufferOption) beforeWatch(request *api.EventsRequest) {
+}
+
+func (o *BufferOption) afterWatch(response *api.EventsResponse) {
+}
+
+// WithAutoReconnect returns a watch option that re-establishes the watch stream when it fails
+// The stream is re-opened with backoff until the watch context is done. Because the watch cannot be resumed
+// from the last revision seen, the client tracks the revision of each key and, once the stream is re-opened,
+// reads the map and sends an event for each key that changed while the stream was down. Events that were
+// already delivered are not repeated.
+// An EventResync event is sent once the stream is re-opened, before the events for the missed changes.
+func WithAutoReconnect() WatchOption {
+	return reconnectOption{}
+}
+
+type reconnectOption struct{}
+
+func (o reconnectOption) beforeWatch(request *api.EventsRequest) {
+}
+
+func (o reconnectOption) afterWatch(response *api.EventsResponse) {
+}
+
+// isAutoReconnect returns a bool indicating whether the given watch options enable auto-reconnect
+func isAutoReconnect(opts []WatchOption) bool {
+	for i := range opts {
+		if _, ok := opts[i].(reconnectOption); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// WithPrevValues returns a watch option that populates the previous entry of watch events
+// The server does not report previous entries, so the client reads the map when the watch is opened and
+// tracks the entries observed by the watch to determine the entry replaced or removed by each change.
+func WithPrevValues() WatchOption {
+	return prevValuesOption{}
+}
+
+type prevValuesOption struct{}
+
+func (o prevValuesOption) beforeWatch(request *api.EventsRequest) {
+}
+
+func (o prevValuesOption) afterWatch(response *api.EventsResponse) {
+}
+
+// isPrevValues returns a bool indicating whether the given watch options enable previous entries
+func isPrevValues(opts []WatchOption) bool {
+	for i := range opts {
+		if _, ok := opts[i].(prevValuesOption); ok {
+			return true
+		}
+	}
+	return false
 }
@@ -19,6 +19,7 @@ import (
 	"github.com/atomix/atomix-go-framework/pkg/atomix/meta"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 )
 
 func TestOptions(t *testing.T) {
@@ -26,6 +27,10 @@ func TestOptions(t *testing.T) {
 	IfMatch(meta.ObjectMeta{Revision: 1}).beforePut(putRequest)
 	assert.Equal(t, meta.Revision(1), meta.Revision(putRequest.Preconditions[0].GetMetadata().Revision.Num))
 
+	putRequest = &api.PutRequest{Entry: api.Entry{Value: &api.Value{}}}
+	WithTTL(time.Second).beforePut(putRequest)
+	assert.Equal(t, time.Second, *putRequest.Entry.Value.TTL)
+
 	removeRequest := &api.RemoveRequest{}
 	IfMatch(meta.ObjectMeta{Revision: 2}).beforeRemove(removeRequest)
 	assert.Equal(t, meta.Revision(2), meta.Revision(removeRequest.Preconditions[0].GetMetadata().Revision.Num))
@@ -34,4 +39,48 @@ func TestOptions(t *testing.T) {
 	assert.False(t, eventRequest.Replay)
 	WithReplay().beforeWatch(eventRequest)
 	assert.True(t, eventRequest.Replay)
+
+	eventRequest = &api.EventsRequest{}
+	WithFilter(Filter{Key: "foo"}).beforeWatch(eventRequest)
+	assert.Equal(t, "foo", eventRequest.Key)
+	eventRequest = &api.EventsRequest{}
+	WithFilter(Filter{Keys: []string{"foo", "bar"}}).beforeWatch(eventRequest)
+	assert.Equal(t, "", eventRequest.Key)
+
+	filter := Filter{Keys: []string{"foo"}, Prefixes: []string{"bar/"}, Types: []EventType{EventInsert, EventRemove}}
+	assert.True(t, filter.matches(Event{Type: EventInsert, Entry: Entry{Key: "foo"}}))
+	assert.True(t, filter.matches(Event{Type: EventRemove, Entry: Entry{Key: "bar/baz"}}))
+	assert.True(t, filter.matches(Event{Type: EventExpire, Entry: Entry{Key: "bar/baz"}}))
+	assert.False(t, filter.matches(Event{Type: EventUpdate, Entry: Entry{Key: "foo"}}))
+	assert.False(t, filter.matches(Event{Type: EventInsert, Entry: Entry{Key: "baz"}}))
+	assert.True(t, Filter{}.matches(Event{Type: EventUpdate, Entry: Entry{Key: "baz"}}))
+
+	batchOptions := newBatchOptions()
+	assert.Equal(t, defaultBatchConcurrency, batchOptions.concurrency)
+	assert.False(t, batchOptions.atomic)
+	batchOptions = newBatchOptions(WithConcurrency(2), WithAtomic())
+	assert.Equal(t, 2, batchOptions.concurrency)
+	assert.True(t, batchOptions.atomic)
+
+	computeOptions := newComputeOptions()
+	assert.Equal(t, -1, computeOptions.maxRetries)
+	assert.Equal(t, defaultInitialBackoff, computeOptions.initialBackoff)
+	assert.Equal(t, defaultMaxBackoff, computeOptions.maxBackoff)
+	computeOptions = newComputeOptions(WithMaxRetries(3), WithBackoff(time.Second, time.Millisecond))
+	assert.Equal(t, 3, computeOptions.maxRetries)
+	assert.Equal(t, time.Second, computeOptions.initialBackoff)
+	assert.Equal(t, time.Second, computeOptions.maxBackoff)
+
+	entriesOptions := newEntriesOptions(WithPrefix("foo/"), WithRange("foo/b", "foo/d"))
+	assert.False(t, entriesOptions.ordered())
+	assert.False(t, entriesOptions.matches("bar/c"))
+	assert.False(t, entriesOptions.matches("foo/a"))
+	assert.True(t, entriesOptions.matches("foo/b"))
+	assert.True(t, entriesOptions.matches("foo/c"))
+	assert.False(t, entriesOptions.matches("foo/d"))
+
+	entriesOptions = newEntriesOptions(WithLimit(10), WithContinuation(ContinuationToken(Entry{Key: "foo/b"})))
+	assert.True(t, entriesOptions.ordered())
+	assert.False(t, entriesOptions.matches("foo/b"))
+	assert.True(t, entriesOptions.matches("foo/c"))
 }
@@ -0,0 +1,213 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package _map //nolint:golint
+
+import (
+	"context"
+	"github.com/atomix/atomix-go-framework/pkg/atomix/errors"
+	"github.com/atomix/atomix-go-framework/pkg/atomix/meta"
+	"sync"
+	"sync/atomic"
+)
+
+// Replica is a local, read-only copy of all the entries in a map
+// Reads are served from memory without locking. The replica is kept up to date by a watch on the map, and is
+// intended for small maps that are read far more often than they're written.
+type Replica interface {
+	// Get gets the entry for the given key
+	Get(key string) (Entry, bool)
+
+	// Range calls the given function for each entry in the replica until the function returns false
+	// The entries are read from a consistent snapshot of the replica.
+	Range(f func(entry Entry) bool)
+
+	// Len returns the number of entries in the replica
+	Len() int
+
+	// Revision returns the highest entry revision applied to the replica
+	// Removal events carry the revision of the removed entry rather than a revision of their own, so they do not
+	// advance the revision of the replica. Use WaitForRemoval to wait for a removal.
+	Revision() meta.Revision
+
+	// Ready returns a channel that's closed once the replica contains all the entries in the map
+	Ready() <-chan struct{}
+
+	// WaitFor waits until the given revision has been applied to the replica
+	// WaitFor can be used with the revision of an entry returned by a write to read the write from the replica.
+	WaitFor(ctx context.Context, revision meta.Revision) error
+
+	// WaitForRemoval waits until the replica no longer holds the given revision of the given key
+	// WaitForRemoval can be used with the entry returned by a removal to wait until the removal is reflected in
+	// the replica. It returns once the key is removed from the replica or replaced by a later revision.
+	WaitForRemoval(ctx context.Context, key string, revision meta.Revision) error
+
+	// Done returns a channel that's closed once the replica stops applying changes from the map
+	Done() <-chan struct{}
+}
+
+// NewReplica creates a new Replica of the given map
+// The replica is populated by replaying the entries in the map, and then applies changes to the map in the
+// order in which they're received. The replica is updated until the given context is done.
+func NewReplica(ctx context.Context, m Map) (Replica, error) {
+	ch := make(chan Event)
+	if err := m.Watch(ctx, ch, WithReplay(), WithAutoReconnect()); err != nil {
+		return nil, err
+	}
+	replica := &replica{
+		changed: make(chan struct{}),
+		ready:   make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	replica.state.Store(&replicaState{
+		entries: make(map[string]Entry),
+	})
+	go replica.apply(ch)
+	return replica, nil
+}
+
+// replicaState is an immutable snapshot of a replica
+type replicaState struct {
+	entries  map[string]Entry
+	revision meta.Revision
+}
+
+// replica is a Replica populated from a map watch
+type replica struct {
+	state   atomic.Value
+	mu      sync.Mutex
+	changed chan struct{}
+	ready   chan struct{}
+	done    chan struct{}
+}
+
+// apply applies the events received on the given channel to the replica
+// Until the replay of the map is complete, replayed entries are collected and published once. Subsequent
+// events are applied to a copy of the replica to allow the current state to be read without locking.
+func (r *replica) apply(ch <-chan Event) {
+	defer close(r.done)
+	entries := make(map[string]Entry)
+	var revision meta.Revision
+	synced := false
+	for event := range ch {
+		// The events that follow a resync bring the replica up to date
+		if event.Type == EventResync {
+			continue
+		}
+		if event.Type == EventSynced {
+			if !synced {
+				synced = true
+				r.publish(entries, revision)
+				close(r.ready)
+			}
+			continue
+		}
+
+		if synced {
+			current := r.state.Load().(*replicaState)
+			entries = make(map[string]Entry, len(current.entries)+1)
+			for key, entry := range current.entries {
+				entries[key] = entry
+			}
+		}
+		switch event.Type {
+		case EventRemove, EventExpire:
+			delete(entries, event.Entry.Key)
+		default:
+			entries[event.Entry.Key] = event.Entry
+		}
+		if event.Entry.Revision > revision {
+			revision = event.Entry.Revision
+		}
+		if synced {
+			r.publish(entries, revision)
+		}
+	}
+}
+
+// publish publishes the given state and wakes up goroutines waiting for a revision
+func (r *replica) publish(entries map[string]Entry, revision meta.Revision) {
+	r.state.Store(&replicaState{
+		entries:  entries,
+		revision: revision,
+	})
+	r.mu.Lock()
+	close(r.changed)
+	r.changed = make(chan struct{})
+	r.mu.Unlock()
+}
+
+func (r *replica) Get(key string) (Entry, bool) {
+	entry, ok := r.state.Load().(*replicaState).entries[key]
+	return entry, ok
+}
+
+func (r *replica) Range(f func(entry Entry) bool) {
+	for _, entry := range r.state.Load().(*replicaState).entries {
+		if !f(entry) {
+			return
+		}
+	}
+}
+
+func (r *replica) Len() int {
+	return len(r.state.Load().(*replicaState).entries)
+}
+
+func (r *replica) Revision() meta.Revision {
+	return r.state.Load().(*replicaState).revision
+}
+
+func (r *replica) Ready() <-chan struct{} {
+	return r.ready
+}
+
+func (r *replica) WaitFor(ctx context.Context, revision meta.Revision) error {
+	return r.wait(ctx, func() bool {
+		return r.Revision() >= revision
+	})
+}
+
+func (r *replica) WaitForRemoval(ctx context.Context, key string, revision meta.Revision) error {
+	return r.wait(ctx, func() bool {
+		entry, ok := r.Get(key)
+		return !ok || entry.Revision > revision
+	})
+}
+
+// wait waits until the given condition holds for the replica
+func (r *replica) wait(ctx context.Context, f func() bool) error {
+	for {
+		r.mu.Lock()
+		changed := r.changed
+		r.mu.Unlock()
+		if f() {
+			return nil
+		}
+		select {
+		case <-changed:
+		case <-r.done:
+			if f() {
+				return nil
+			}
+			return errors.NewUnavailable("replica is closed")
+		case <-ctx.Done():
+			return errors.From(ctx.Err())
+		}
+	}
+}
+
+func (r *replica) Done() <-chan struct{} {
+	return r.done
+}
@@ -0,0 +1,170 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package _map //nolint:golint
+
+import (
+	"context"
+	"github.com/atomix/atomix-go-framework/pkg/atomix/errors"
+)
+
+// Transaction is a best-effort multi-key write
+// Transactions are not atomic. A transaction is built by adding preconditions and writes and is applied by calling
+// Commit. When the transaction is committed, the current entry for each key in the transaction is read and the
+// preconditions are checked. The writes are then applied one key at a time, each conditional on the entry read.
+// If any key was modified concurrently with the commit, the writes that were applied are rolled back and a conflict
+// error naming the key is returned. Concurrent readers may observe the transaction partially applied before it's
+// rolled back, and a write that cannot be rolled back remains applied.
+// Preconditions on keys that are not written by the transaction are checked only when the entries are read, so
+// such a key may be modified before the writes are applied.
+type Transaction interface {
+	// If adds a precondition that the given key is at the given version
+	// A version of 0 requires that the key not be present in the map.
+	If(key string, version Version) Transaction
+
+	// Put adds a write of the given value to the given key
+	Put(key string, value []byte) Transaction
+
+	// Remove adds a removal of the given key
+	// Removing a key that's not present in the map is a no-op.
+	Remove(key string) Transaction
+
+	// Commit applies the transaction
+	// A result is returned for each key written by the transaction in the order in which the keys were added.
+	// If the transaction fails and a write cannot be rolled back, the returned error names the key and the
+	// key's result reports the failure in its RollbackError.
+	Commit(ctx context.Context, opts ...BatchOption) ([]BatchResult, error)
+}
+
+// txnCondition is a transaction precondition
+type txnCondition struct {
+	key     string
+	version Version
+}
+
+// txnWrite is a transaction write
+type txnWrite struct {
+	key    string
+	value  []byte
+	remove bool
+}
+
+func newTransaction(m Map) Transaction {
+	return &transaction{
+		m: m,
+	}
+}
+
+// transaction is the default Transaction implementation
+type transaction struct {
+	m          Map
+	conditions []txnCondition
+	writes     []txnWrite
+}
+
+func (t *transaction) If(key string, version Version) Transaction {
+	t.conditions = append(t.conditions, txnCondition{
+		key:     key,
+		version: version,
+	})
+	return t
+}
+
+func (t *transaction) Put(key string, value []byte) Transaction {
+	t.addWrite(txnWrite{
+		key:   key,
+		value: value,
+	})
+	return t
+}
+
+func (t *transaction) Remove(key string) Transaction {
+	t.addWrite(txnWrite{
+		key:    key,
+		remove: true,
+	})
+	return t
+}
+
+// addWrite adds a write to the transaction, replacing any prior write to the same key
+func (t *transaction) addWrite(write txnWrite) {
+	for i := range t.writes {
+		if t.writes[i].key == write.key {
+			t.writes[i] = write
+			return
+		}
+	}
+	t.writes = append(t.writes, write)
+}
+
+func (t *transaction) Commit(ctx context.Context, opts ...BatchOption) ([]BatchResult, error) {
+	options := newBatchOptions(opts...)
+
+	// Read the current entry for every key referenced by the transaction
+	keys := make([]string, 0, len(t.conditions)+len(t.writes))
+	indexes := make(map[string]int)
+	for _, condition := range t.conditions {
+		if _, ok := indexes[condition.key]; !ok {
+			indexes[condition.key] = len(keys)
+			keys = append(keys, condition.key)
+		}
+	}
+	for _, write := range t.writes {
+		if _, ok := indexes[write.key]; !ok {
+			indexes[write.key] = len(keys)
+			keys = append(keys, write.key)
+		}
+	}
+	reads := make([]batchWrite, len(keys))
+	for i, key := range keys {
+		reads[i] = batchWrite{key: key}
+	}
+	if err := readBatchWrites(ctx, t.m, reads, options); err != nil {
+		return nil, err
+	}
+
+	for _, condition := range t.conditions {
+		prev := reads[indexes[condition.key]].prev
+		if condition.version == 0 && prev == nil {
+			continue
+		}
+		if prev == nil || Version(prev.Revision) != condition.version {
+			return nil, errors.NewConflict("precondition failed for key '%s'", condition.key)
+		}
+	}
+
+	writes := make([]batchWrite, 0, len(t.writes))
+	results := make([]BatchResult, len(t.writes))
+	positions := make([]int, 0, len(t.writes))
+	for i, write := range t.writes {
+		prev := reads[indexes[write.key]].prev
+		results[i] = BatchResult{Key: write.key}
+		if write.remove && prev == nil {
+			continue
+		}
+		writes = append(writes, batchWrite{
+			key:    write.key,
+			value:  write.value,
+			remove: write.remove,
+			prev:   prev,
+		})
+		positions = append(positions, i)
+	}
+
+	applied, err := applyBatchWrites(ctx, t.m, writes, options)
+	for i, result := range applied {
+		results[positions[i]] = result
+	}
+	return results, err
+}
@@ -0,0 +1,212 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package _map //nolint:golint
+
+import (
+	"context"
+	api "github.com/atomix/atomix-api/go/atomix/primitive/map"
+	"github.com/atomix/atomix-go-client/pkg/atomix/primitive"
+	"github.com/atomix/atomix-go-framework/pkg/atomix/meta"
+	"io"
+)
+
+func newRevisionTracker() *revisionTracker {
+	return &revisionTracker{
+		revisions: make(map[string]meta.Revision),
+	}
+}
+
+// revisionTracker tracks the revision of each key in the map as observed by a watch
+// Once the watch has been resynchronized, the tracked revisions are exactly the state delivered to the
+// consumer, and events already reflected in that state are discarded as duplicates.
+type revisionTracker struct {
+	revisions map[string]meta.Revision
+	dedupe    bool
+}
+
+// update updates the tracked revisions with the given event, returning false if the event is a duplicate
+func (t *revisionTracker) update(event Event) bool {
+	key := event.Entry.Key
+	revision, ok := t.revisions[key]
+	switch event.Type {
+	case EventRemove, EventExpire:
+		if t.dedupe && (!ok || event.Entry.Revision < revision) {
+			return false
+		}
+		delete(t.revisions, key)
+	default:
+		if ok && event.Entry.Revision <= revision {
+			return !t.dedupe
+		}
+		t.revisions[key] = event.Entry.Revision
+	}
+	return true
+}
+
+func newEntryView() *entryView {
+	return &entryView{
+		entries: make(map[string]Entry),
+	}
+}
+
+// entryView tracks the entries in the map as observed by a watch to populate the previous entry of events
+type entryView struct {
+	entries map[string]Entry
+}
+
+// update applies the given event to the view and sets the previous entry of the event
+// Events older than the tracked entry for the key precede the view; they're given no previous entry
+// and are not applied.
+func (v *entryView) update(event *Event) {
+	key := event.Entry.Key
+	prev, ok := v.entries[key]
+	if ok && event.Entry.Revision < prev.Revision {
+		return
+	}
+	switch event.Type {
+	case EventRemove, EventExpire:
+		if ok {
+			event.PrevEntry = &prev
+		}
+		delete(v.entries, key)
+	default:
+		if ok && event.Entry.Revision == prev.Revision {
+			return
+		}
+		if ok {
+			event.PrevEntry = &prev
+		}
+		v.entries[key] = event.Entry
+	}
+}
+
+// readEntries reads all the entries in the map, or the entry for the given key if the key is not empty
+func (m *_map) readEntries(ctx context.Context, key string) ([]Entry, error) {
+	iterator, err := m.openEntries(ctx, entriesOptions{}, true)
+	if err != nil {
+		return nil, err
+	}
+	defer iterator.Close()
+	entries := make([]Entry, 0)
+	for {
+		entry, err := iterator.Next(ctx)
+		if err == io.EOF {
+			return entries, nil
+		} else if err != nil {
+			return nil, err
+		}
+		if key == "" || entry.Key == key {
+			entries = append(entries, entry)
+		}
+	}
+}
+
+// replay sends the entries in the map as EventReplay events
+// The entries are read once the watch stream is open, so changes made after the entries are read are delivered
+// by the stream. The replayed entries are tracked to discard events for changes made before they were read.
+func (m *_map) replay(ctx context.Context, key string, tracker *revisionTracker, send func(Event) bool) error {
+	entries, err := m.readEntries(ctx, key)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		tracker.revisions[entry.Key] = entry.Revision
+		if !send(Event{Type: EventReplay, Entry: entry}) {
+			return ctx.Err()
+		}
+	}
+	tracker.dedupe = true
+	return nil
+}
+
+// resync re-establishes a failed watch stream
+// The stream is re-opened and the entries in the map are compared to the tracked revisions to send the
+// changes that were missed while the stream was down. Intermediate changes to a key are collapsed into a
+// single event, and keys removed while the stream was down are sent as EventRemove events with no value.
+// An EventResync event is sent before any of these events.
+// The new stream and a function to cancel it are returned once the watch has been resynchronized.
+func (m *_map) resync(ctx context.Context, request *api.EventsRequest, tracker *revisionTracker, send func(Event) bool) (api.MapService_EventsClient, context.CancelFunc, error) {
+	request = &api.EventsRequest{
+		Headers: request.Headers,
+		Key:     request.Key,
+	}
+
+	var stream api.MapService_EventsClient
+	var cancel context.CancelFunc
+	var entries []Entry
+	err := primitive.RetryWithBackoff(ctx, func() error {
+		var streamCtx context.Context
+		streamCtx, cancel = context.WithCancel(ctx)
+		var err error
+		stream, err = m.client.Events(streamCtx, request)
+		if err != nil {
+			cancel()
+			log.Warnf("Failed to reconnect watch: %v", err)
+			return err
+		}
+		entries, err = m.readEntries(ctx, request.Key)
+		if err != nil {
+			cancel()
+			log.Warnf("Failed to resynchronize watch: %v", err)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !send(Event{Type: EventResync}) {
+		cancel()
+		return nil, nil, ctx.Err()
+	}
+
+	revisions := make(map[string]meta.Revision)
+	for _, entry := range entries {
+		revisions[entry.Key] = entry.Revision
+		revision, ok := tracker.revisions[entry.Key]
+		if !ok {
+			if !send(Event{Type: EventInsert, Entry: entry}) {
+				cancel()
+				return nil, nil, ctx.Err()
+			}
+		} else if revision != entry.Revision {
+			if !send(Event{Type: EventUpdate, Entry: entry}) {
+				cancel()
+				return nil, nil, ctx.Err()
+			}
+		}
+	}
+	for key, revision := range tracker.revisions {
+		if _, ok := revisions[key]; !ok {
+			event := Event{
+				Type: EventRemove,
+				Entry: Entry{
+					ObjectMeta: meta.ObjectMeta{
+						Revision: revision,
+					},
+					Key: key,
+				},
+			}
+			if !send(event) {
+				cancel()
+				return nil, nil, ctx.Err()
+			}
+		}
+	}
+	tracker.revisions = revisions
+	tracker.dedupe = true
+	return stream, cancel, nil
+}
@@ -0,0 +1,329 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multimap
+
+import (
+	"context"
+	_map "github.com/atomix/atomix-go-client/pkg/atomix/map"
+	"github.com/atomix/atomix-go-client/pkg/atomix/primitive"
+	"github.com/atomix/atomix-go-framework/pkg/atomix/errors"
+	"github.com/atomix/atomix-go-framework/pkg/atomix/logging"
+	"google.golang.org/grpc"
+	"io"
+)
+
+var log = logging.GetLogger("atomix", "client", "multimap")
+
+// Type is the multimap type
+// Multimaps are stored in a Map primitive, with the values of each key encoded in a single map entry. The Map is
+// named with MapName so that a multimap does not share its entries with the Map of the same name.
+const Type primitive.Type = _map.Type
+
+// mapPrefix is the prefix of the name of the Map in which a multimap is stored
+const mapPrefix = "multimap."
+
+// MapName returns the name of the Map primitive in which the multimap of the given name is stored
+func MapName(name string) string {
+	return mapPrefix + name
+}
+
+// Client provides an API for creating Multimaps
+type Client interface {
+	// GetMultimap gets the Multimap instance of the given name
+	GetMultimap(ctx context.Context, name string, opts ...primitive.Option) (Multimap, error)
+}
+
+// Multimap is a distributed map in which each key holds a set of values
+// The values of a key are updated atomically with version checks, so concurrent updates to the same key
+// by different clients are never lost.
+type Multimap interface {
+	primitive.Primitive
+
+	// Put adds a value to the given key
+	// Returns a bool indicating whether the value was added. If the key already holds the value, the multimap
+	// is not modified.
+	Put(ctx context.Context, key string, value []byte) (bool, error)
+
+	// Get gets the values held by the given key
+	// If the key is not present, an empty slice is returned.
+	Get(ctx context.Context, key string) ([][]byte, error)
+
+	// ContainsEntry returns a bool indicating whether the given key holds the given value
+	ContainsEntry(ctx context.Context, key string, value []byte) (bool, error)
+
+	// Remove removes a value from the given key
+	// Returns a bool indicating whether the value was removed. The key is removed once it holds no values.
+	Remove(ctx context.Context, key string, value []byte) (bool, error)
+
+	// RemoveAll removes the given key and returns the values it held
+	RemoveAll(ctx context.Context, key string) ([][]byte, error)
+
+	// Clear removes all keys from the multimap
+	Clear(ctx context.Context) error
+
+	// Entries lists the key/value pairs in the multimap
+	// This is a non-blocking method. If the method returns without error, an entry is pushed onto the given
+	// channel for each value of each key, and the channel is closed once all entries have been read.
+	Entries(ctx context.Context, ch chan<- Entry) error
+
+	// Watch watches the multimap for changes
+	// This is a non-blocking method. If the method returns without error, an event is pushed onto the given
+	// channel for each value added to or removed from a key.
+	Watch(ctx context.Context, ch chan<- Event, opts ...WatchOption) error
+}
+
+// Entry is a key/value pair in a multimap
+type Entry struct {
+	// Key is the entry key
+	Key string
+
+	// Value is the entry value
+	Value []byte
+}
+
+// EventType is the type of a multimap event
+type EventType string
+
+const (
+	// EventAdd indicates a value was added to a key
+	EventAdd EventType = "add"
+
+	// EventRemove indicates a value was removed from a key
+	EventRemove EventType = "remove"
+
+	// EventReplay indicates a value was replayed
+	EventReplay EventType = "replay"
+
+	// EventSynced indicates all existing values have been replayed
+	EventSynced EventType = "synced"
+)
+
+// Event is a multimap change event
+type Event struct {
+	// Type indicates the change event type
+	Type EventType
+
+	// Key is the key that was changed
+	Key string
+
+	// Value is the value that was added or removed
+	Value []byte
+}
+
+// New creates a new multimap
+// The multimap is stored in the Map named by MapName, which must be served by the given connection.
+func New(ctx context.Context, name string, conn *grpc.ClientConn, opts ...primitive.Option) (Multimap, error) {
+	m, err := _map.New(ctx, MapName(name), conn, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &multimap{
+		Primitive: m,
+		m:         m,
+	}, nil
+}
+
+// multimap is the implementation of Multimap on a Map primitive
+type multimap struct {
+	primitive.Primitive
+	m _map.Map
+}
+
+// errUnchanged is returned by compute functions to abort updates that would not change the values of a key
+var errUnchanged = errors.NewConflict("values are unchanged")
+
+func (m *multimap) Put(ctx context.Context, key string, value []byte) (bool, error) {
+	_, err := m.m.Compute(ctx, key, func(entry *_map.Entry) ([]byte, error) {
+		values, err := entryValues(entry)
+		if err != nil {
+			return nil, err
+		}
+		values, added := addValue(values, value)
+		if !added {
+			return nil, errUnchanged
+		}
+		return encodeValues(values), nil
+	})
+	if err == errUnchanged {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (m *multimap) Get(ctx context.Context, key string) ([][]byte, error) {
+	entry, err := m.m.Get(ctx, key)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return [][]byte{}, nil
+		}
+		return nil, err
+	}
+	return entryValues(entry)
+}
+
+func (m *multimap) ContainsEntry(ctx context.Context, key string, value []byte) (bool, error) {
+	values, err := m.Get(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	_, ok := findValue(values, value)
+	return ok, nil
+}
+
+func (m *multimap) Remove(ctx context.Context, key string, value []byte) (bool, error) {
+	_, err := m.m.Compute(ctx, key, func(entry *_map.Entry) ([]byte, error) {
+		values, err := entryValues(entry)
+		if err != nil {
+			return nil, err
+		}
+		values, removed := removeValue(values, value)
+		if !removed {
+			return nil, errUnchanged
+		}
+		if len(values) == 0 {
+			return nil, nil
+		}
+		return encodeValues(values), nil
+	})
+	if err == errUnchanged {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (m *multimap) RemoveAll(ctx context.Context, key string) ([][]byte, error) {
+	entry, err := m.m.Remove(ctx, key)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return [][]byte{}, nil
+		}
+		return nil, err
+	}
+	return entryValues(entry)
+}
+
+func (m *multimap) Clear(ctx context.Context) error {
+	return m.m.Clear(ctx)
+}
+
+func (m *multimap) Entries(ctx context.Context, ch chan<- Entry) error {
+	iterator, err := m.m.Iterate(ctx)
+	if err != nil {
+		return err
+	}
+	go func() {
+		defer close(ch)
+		defer iterator.Close()
+		for {
+			entry, err := iterator.Next(ctx)
+			if err != nil {
+				if err != io.EOF && !errors.IsCanceled(err) && !errors.IsTimeout(err) {
+					log.Errorf("Entries failed: %v", err)
+				}
+				return
+			}
+			values, err := entryValues(&entry)
+			if err != nil {
+				log.Errorf("Failed to decode values of key '%s': %v", entry.Key, err)
+				continue
+			}
+			for _, value := range values {
+				select {
+				case ch <- Entry{Key: entry.Key, Value: value}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+func (m *multimap) Watch(ctx context.Context, ch chan<- Event, opts ...WatchOption) error {
+	options := newWatchOptions(opts...)
+	mapOpts := []_map.WatchOption{_map.WithPrevValues()}
+	if options.replay {
+		mapOpts = append(mapOpts, _map.WithReplay())
+	}
+	mapCh := make(chan _map.Event)
+	if err := m.m.Watch(ctx, mapCh, mapOpts...); err != nil {
+		return err
+	}
+	go func() {
+		defer close(ch)
+		for mapEvent := range mapCh {
+			for _, event := range valueEvents(mapEvent) {
+				select {
+				case ch <- event:
+				case <-ctx.Done():
+					// Drain the map events so the map watch can observe the context and exit
+					for range mapCh {
+					}
+					return
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// valueEvents returns the per-value events for the given map event
+func valueEvents(event _map.Event) []Event {
+	if event.Type == _map.EventSynced {
+		return []Event{{Type: EventSynced}}
+	}
+
+	key := event.Entry.Key
+	var values [][]byte
+	if event.Type != _map.EventRemove && event.Type != _map.EventExpire {
+		var err error
+		values, err = entryValues(&event.Entry)
+		if err != nil {
+			log.Errorf("Failed to decode values of key '%s': %v", key, err)
+			return nil
+		}
+	}
+
+	if event.Type == _map.EventReplay {
+		events := make([]Event, 0, len(values))
+		for _, value := range values {
+			events = append(events, Event{Type: EventReplay, Key: key, Value: value})
+		}
+		return events
+	}
+
+	prevValues, err := entryValues(event.PrevEntry)
+	if err != nil {
+		log.Errorf("Failed to decode values of key '%s': %v", key, err)
+		return nil
+	}
+
+	events := make([]Event, 0)
+	for _, value := range prevValues {
+		if _, ok := findValue(values, value); !ok {
+			events = append(events, Event{Type: EventRemove, Key: key, Value: value})
+		}
+	}
+	for _, value := range values {
+		if _, ok := findValue(prevValues, value); !ok {
+			events = append(events, Event{Type: EventAdd, Key: key, Value: value})
+		}
+	}
+	return events
+}
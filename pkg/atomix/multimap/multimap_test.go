@@ -0,0 +1,218 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multimap
+
+import (
+	"context"
+	primitiveapi "github.com/atomix/atomix-api/go/atomix/primitive"
+	_map "github.com/atomix/atomix-go-client/pkg/atomix/map"
+	"github.com/atomix/atomix-go-client/pkg/atomix/util/test"
+	"github.com/atomix/atomix-go-framework/pkg/atomix/logging"
+	"github.com/stretchr/testify/assert"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestMultimapOperations(t *testing.T) {
+	logging.SetLevel(logging.DebugLevel)
+
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      MapName("TestMultimapOperations"),
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn1, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	conn2, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	m1, err := New(context.TODO(), "TestMultimapOperations", conn1)
+	assert.NoError(t, err)
+
+	m2, err := New(context.TODO(), "TestMultimapOperations", conn2)
+	assert.NoError(t, err)
+
+	values, err := m1.Get(context.TODO(), "alice")
+	assert.NoError(t, err)
+	assert.Len(t, values, 0)
+
+	added, err := m1.Put(context.TODO(), "alice", []byte("session-2"))
+	assert.NoError(t, err)
+	assert.True(t, added)
+	added, err = m1.Put(context.TODO(), "alice", []byte("session-1"))
+	assert.NoError(t, err)
+	assert.True(t, added)
+	added, err = m2.Put(context.TODO(), "alice", []byte("session-1"))
+	assert.NoError(t, err)
+	assert.False(t, added)
+
+	values, err = m2.Get(context.TODO(), "alice")
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("session-1"), []byte("session-2")}, values)
+
+	contains, err := m2.ContainsEntry(context.TODO(), "alice", []byte("session-2"))
+	assert.NoError(t, err)
+	assert.True(t, contains)
+	contains, err = m2.ContainsEntry(context.TODO(), "bob", []byte("session-2"))
+	assert.NoError(t, err)
+	assert.False(t, contains)
+
+	_, err = m1.Put(context.TODO(), "bob", []byte("session-3"))
+	assert.NoError(t, err)
+
+	ch := make(chan Entry)
+	assert.NoError(t, m1.Entries(context.TODO(), ch))
+	entries := make(map[string]int)
+	for entry := range ch {
+		entries[entry.Key+"/"+string(entry.Value)]++
+	}
+	assert.Equal(t, map[string]int{"alice/session-1": 1, "alice/session-2": 1, "bob/session-3": 1}, entries)
+
+	removed, err := m2.Remove(context.TODO(), "alice", []byte("session-2"))
+	assert.NoError(t, err)
+	assert.True(t, removed)
+	removed, err = m2.Remove(context.TODO(), "alice", []byte("session-2"))
+	assert.NoError(t, err)
+	assert.False(t, removed)
+
+	values, err = m1.RemoveAll(context.TODO(), "alice")
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("session-1")}, values)
+	values, err = m1.Get(context.TODO(), "alice")
+	assert.NoError(t, err)
+	assert.Len(t, values, 0)
+
+	// Removing the last value removes the key
+	removed, err = m1.Remove(context.TODO(), "bob", []byte("session-3"))
+	assert.NoError(t, err)
+	assert.True(t, removed)
+	ch = make(chan Entry)
+	assert.NoError(t, m1.Entries(context.TODO(), ch))
+	_, ok := <-ch
+	assert.False(t, ok)
+
+	// Concurrent puts to the same key are not lost
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(m Multimap, i int) {
+			defer wg.Done()
+			_, err := m.Put(context.Background(), "carol", []byte(strconv.Itoa(i)))
+			assert.NoError(t, err)
+		}([]Multimap{m1, m2}[i%2], i)
+	}
+	wg.Wait()
+	values, err = m1.Get(context.TODO(), "carol")
+	assert.NoError(t, err)
+	assert.Len(t, values, 10)
+
+	assert.NoError(t, test.Stop())
+}
+
+func TestMultimapWatch(t *testing.T) {
+	logging.SetLevel(logging.DebugLevel)
+
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      MapName("TestMultimapWatch"),
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	m, err := New(context.TODO(), "TestMultimapWatch", conn)
+	assert.NoError(t, err)
+
+	_, err = m.Put(context.TODO(), "alice", []byte("session-1"))
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := make(chan Event)
+	assert.NoError(t, m.Watch(ctx, ch, WithReplay()))
+
+	event := <-ch
+	assert.Equal(t, EventReplay, event.Type)
+	assert.Equal(t, "alice", event.Key)
+	assert.Equal(t, "session-1", string(event.Value))
+	event = <-ch
+	assert.Equal(t, EventSynced, event.Type)
+
+	_, err = m.Put(context.TODO(), "alice", []byte("session-2"))
+	assert.NoError(t, err)
+	event = <-ch
+	assert.Equal(t, EventAdd, event.Type)
+	assert.Equal(t, "alice", event.Key)
+	assert.Equal(t, "session-2", string(event.Value))
+
+	_, err = m.Remove(context.TODO(), "alice", []byte("session-1"))
+	assert.NoError(t, err)
+	event = <-ch
+	assert.Equal(t, EventRemove, event.Type)
+	assert.Equal(t, "alice", event.Key)
+	assert.Equal(t, "session-1", string(event.Value))
+
+	_, err = m.RemoveAll(context.TODO(), "alice")
+	assert.NoError(t, err)
+	event = <-ch
+	assert.Equal(t, EventRemove, event.Type)
+	assert.Equal(t, "session-2", string(event.Value))
+
+	assert.NoError(t, test.Stop())
+}
+
+func TestMultimapName(t *testing.T) {
+	logging.SetLevel(logging.DebugLevel)
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	// A multimap does not share its entries with the Map of the same name
+	multimapConn, err := test.CreateProxy(primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      MapName("TestMultimapName"),
+	})
+	assert.NoError(t, err)
+	mapConn, err := test.CreateProxy(primitiveapi.PrimitiveId{
+		Type:      _map.Type.String(),
+		Namespace: "test",
+		Name:      "TestMultimapName",
+	})
+	assert.NoError(t, err)
+
+	multimap, err := New(context.TODO(), "TestMultimapName", multimapConn)
+	assert.NoError(t, err)
+	m, err := _map.New(context.TODO(), "TestMultimapName", mapConn)
+	assert.NoError(t, err)
+
+	_, err = m.Put(context.TODO(), "foo", []byte("bar"))
+	assert.NoError(t, err)
+	values, err := multimap.Get(context.TODO(), "foo")
+	assert.NoError(t, err)
+	assert.Len(t, values, 0)
+
+	assert.NoError(t, test.Stop())
+}
@@ -0,0 +1,45 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multimap
+
+// WatchOption is an option for multimap Watch calls
+type WatchOption interface {
+	applyWatch(options *watchOptions)
+}
+
+// watchOptions is watch options
+type watchOptions struct {
+	replay bool
+}
+
+func newWatchOptions(opts ...WatchOption) watchOptions {
+	options := watchOptions{}
+	for _, opt := range opts {
+		opt.applyWatch(&options)
+	}
+	return options
+}
+
+// WithReplay returns a watch option that replays the existing values before watching for changes
+// Each value is sent as an EventReplay event, followed by a single EventSynced event once the replay is complete.
+func WithReplay() WatchOption {
+	return replayOption{}
+}
+
+type replayOption struct{}
+
+func (o replayOption) applyWatch(options *watchOptions) {
+	options.replay = true
+}
@@ -0,0 +1,88 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multimap
+
+import (
+	"bytes"
+	"encoding/binary"
+	_map "github.com/atomix/atomix-go-client/pkg/atomix/map"
+	"github.com/atomix/atomix-go-framework/pkg/atomix/errors"
+	"sort"
+)
+
+// entryValues decodes the values stored in the given map entry
+// A nil entry holds no values.
+func entryValues(entry *_map.Entry) ([][]byte, error) {
+	if entry == nil {
+		return [][]byte{}, nil
+	}
+	return decodeValues(entry.Value)
+}
+
+// encodeValues encodes a sorted set of values as a sequence of length-prefixed values
+func encodeValues(values [][]byte) []byte {
+	buf := &bytes.Buffer{}
+	length := make([]byte, binary.MaxVarintLen64)
+	for _, value := range values {
+		n := binary.PutUvarint(length, uint64(len(value)))
+		buf.Write(length[:n])
+		buf.Write(value)
+	}
+	return buf.Bytes()
+}
+
+// decodeValues decodes a sequence of length-prefixed values
+func decodeValues(value []byte) ([][]byte, error) {
+	values := make([][]byte, 0)
+	for len(value) > 0 {
+		length, n := binary.Uvarint(value)
+		if n <= 0 || uint64(len(value)-n) < length {
+			return nil, errors.NewInvalid("malformed multimap values")
+		}
+		value = value[n:]
+		values = append(values, value[:length:length])
+		value = value[length:]
+	}
+	return values, nil
+}
+
+// findValue returns the position of the given value in a sorted set of values, and whether the set contains it
+func findValue(values [][]byte, value []byte) (int, bool) {
+	i := sort.Search(len(values), func(i int) bool {
+		return bytes.Compare(values[i], value) >= 0
+	})
+	return i, i < len(values) && bytes.Equal(values[i], value)
+}
+
+// addValue adds a value to a sorted set of values, returning false if the set already contains it
+func addValue(values [][]byte, value []byte) ([][]byte, bool) {
+	i, ok := findValue(values, value)
+	if ok {
+		return values, false
+	}
+	values = append(values, nil)
+	copy(values[i+1:], values[i:])
+	values[i] = value
+	return values, true
+}
+
+// removeValue removes a value from a sorted set of values, returning false if the set does not contain it
+func removeValue(values [][]byte, value []byte) ([][]byte, bool) {
+	i, ok := findValue(values, value)
+	if !ok {
+		return values, false
+	}
+	return append(values[:i], values[i+1:]...), true
+}
@@ -0,0 +1,267 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package primitive
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// OverflowPolicy determines how a watch buffer handles events when it's full
+type OverflowPolicy int
+
+const (
+	// OverflowBlock blocks the watch stream until the consumer reads an event from the buffer
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropOldest drops the oldest buffered event to make room for the new event
+	OverflowDropOldest
+	// OverflowDropNewest drops the new event
+	OverflowDropNewest
+	// OverflowCoalesce replaces the buffered event for the same key with the new event
+	// If no event for the same key is buffered, the oldest buffered event is dropped. Marker events, such as
+	// the end of a replay or a resync, are never coalesced or dropped.
+	OverflowCoalesce
+)
+
+// WatchBuffer configures the buffering of events between a watch stream and the watch channel
+// The buffer decouples the stream from the consumer, so a slow consumer does not stall the stream unless
+// the OverflowBlock policy is used. When the buffer is full, events are handled according to the policy.
+// Events dropped due to overflow are counted by the buffer. Primitives expose the buffer as a watch option
+// with a WithBuffer function that embeds the WatchBuffer, so the number of dropped events can be read from
+// the option.
+type WatchBuffer struct {
+	// Size is the maximum number of events buffered
+	Size int
+	// Policy is the policy applied when the buffer is full
+	Policy OverflowPolicy
+
+	dropped uint64
+}
+
+// NewWatchBuffer returns a configuration for buffering up to size events with the given overflow policy
+func NewWatchBuffer(size int, policy OverflowPolicy) *WatchBuffer {
+	return &WatchBuffer{
+		Size:   size,
+		Policy: policy,
+	}
+}
+
+// watchBufferOption is implemented by watch options that embed a WatchBuffer
+type watchBufferOption interface {
+	watchBuffer() *WatchBuffer
+}
+
+func (b *WatchBuffer) watchBuffer() *WatchBuffer {
+	return b
+}
+
+// GetWatchBuffer returns the buffer configuration embedded in the given slice of watch options, if any
+func GetWatchBuffer(opts interface{}) *WatchBuffer {
+	var config *WatchBuffer
+	values := reflect.ValueOf(opts)
+	if values.Kind() != reflect.Slice {
+		return nil
+	}
+	for i := 0; i < values.Len(); i++ {
+		if option, ok := values.Index(i).Interface().(watchBufferOption); ok {
+			config = option.watchBuffer()
+		}
+	}
+	return config
+}
+
+// Dropped returns the number of events dropped due to overflow
+// If the buffer configuration is used for multiple watches, the count is the total for all watches.
+func (b *WatchBuffer) Dropped() uint64 {
+	return atomic.LoadUint64(&b.dropped)
+}
+
+// NewEventBuffer creates a new event buffer with the given configuration
+// If the configuration is nil, the buffer holds a single event and blocks when it's full.
+func NewEventBuffer(config *WatchBuffer) *EventBuffer {
+	if config == nil {
+		config = &WatchBuffer{}
+	}
+	size := config.Size
+	if size < 1 {
+		size = 1
+	}
+	return &EventBuffer{
+		config: config,
+		size:   size,
+		notify: make(chan struct{}, 1),
+		space:  make(chan struct{}, 1),
+	}
+}
+
+// bufferedEvent is an event held in an EventBuffer
+type bufferedEvent struct {
+	key   string
+	event interface{}
+}
+
+// isMarker returns a bool indicating whether the event is a marker event
+func (e bufferedEvent) isMarker() bool {
+	return e.key == ""
+}
+
+// EventBuffer is a bounded queue of events read from a watch stream
+// Events are pushed by the goroutine reading the stream and read by the goroutine writing to the watch channel.
+type EventBuffer struct {
+	config *WatchBuffer
+	size   int
+	events []bufferedEvent
+	closed bool
+	notify chan struct{}
+	space  chan struct{}
+	mu     sync.Mutex
+}
+
+// Push adds an event with the given key to the buffer, applying the overflow policy if the buffer is full
+// Events with an empty key are markers, such as the end of a replay, and are never coalesced or dropped.
+// Push returns false if the context is done before the event could be buffered.
+func (b *EventBuffer) Push(ctx context.Context, key string, event interface{}) bool {
+	pushed := bufferedEvent{key: key, event: event}
+	for {
+		b.mu.Lock()
+		if len(b.events) < b.size {
+			b.events = append(b.events, pushed)
+			b.mu.Unlock()
+			signal(b.notify)
+			return true
+		}
+
+		switch b.config.Policy {
+		case OverflowDropOldest:
+			if i := b.oldestEvent(); i >= 0 {
+				copy(b.events[i:], b.events[i+1:])
+				b.events[len(b.events)-1] = pushed
+				b.mu.Unlock()
+				atomic.AddUint64(&b.config.dropped, 1)
+				return true
+			}
+		case OverflowDropNewest:
+			if !pushed.isMarker() {
+				b.mu.Unlock()
+				atomic.AddUint64(&b.config.dropped, 1)
+				return true
+			}
+		case OverflowCoalesce:
+			if !pushed.isMarker() {
+				// Only events pushed since the last marker are coalesced to preserve ordering around markers
+				for i := len(b.events) - 1; i >= 0 && !b.events[i].isMarker(); i-- {
+					if b.events[i].key == key {
+						b.events[i].event = event
+						b.mu.Unlock()
+						atomic.AddUint64(&b.config.dropped, 1)
+						return true
+					}
+				}
+			}
+			if i := b.oldestEvent(); i >= 0 {
+				copy(b.events[i:], b.events[i+1:])
+				b.events[len(b.events)-1] = pushed
+				b.mu.Unlock()
+				atomic.AddUint64(&b.config.dropped, 1)
+				return true
+			}
+		}
+		b.mu.Unlock()
+
+		select {
+		case <-b.space:
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+// oldestEvent returns the position of the oldest buffered event that is not a marker, or -1 if there is none
+func (b *EventBuffer) oldestEvent() int {
+	for i := range b.events {
+		if !b.events[i].isMarker() {
+			return i
+		}
+	}
+	return -1
+}
+
+// Next returns the next event in the buffer, waiting for an event if the buffer is empty
+// Next returns false once the buffer is closed and all buffered events have been read, or if the context
+// is done before an event is available.
+func (b *EventBuffer) Next(ctx context.Context) (interface{}, bool) {
+	for {
+		b.mu.Lock()
+		if len(b.events) > 0 {
+			event := b.events[0]
+			b.events = b.events[1:]
+			b.mu.Unlock()
+			signal(b.space)
+			return event.event, true
+		}
+		if b.closed {
+			b.mu.Unlock()
+			return nil, false
+		}
+		b.mu.Unlock()
+
+		select {
+		case <-b.notify:
+		case <-ctx.Done():
+			return nil, false
+		}
+	}
+}
+
+// Forward sends the events read from the buffer to the given channel
+// The channel must be a channel to which the buffered events can be sent. The channel is closed once the
+// buffer is closed and all buffered events have been sent, or once the context is done.
+func (b *EventBuffer) Forward(ctx context.Context, ch interface{}) {
+	channel := reflect.ValueOf(ch)
+	defer channel.Close()
+	cases := []reflect.SelectCase{
+		{Dir: reflect.SelectSend, Chan: channel},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())},
+	}
+	for {
+		event, ok := b.Next(ctx)
+		if !ok {
+			return
+		}
+		cases[0].Send = reflect.ValueOf(event)
+		if chosen, _, _ := reflect.Select(cases); chosen != 0 {
+			return
+		}
+	}
+}
+
+// Close closes the buffer
+// Events already in the buffer can still be read.
+func (b *EventBuffer) Close() {
+	b.mu.Lock()
+	b.closed = true
+	b.mu.Unlock()
+	signal(b.notify)
+}
+
+// signal performs a non-blocking send on the given channel
+func signal(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package primitive
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func readAll(buffer *EventBuffer) []interface{} {
+	buffer.Close()
+	events := make([]interface{}, 0)
+	for {
+		event, ok := buffer.Next(context.Background())
+		if !ok {
+			return events
+		}
+		events = append(events, event)
+	}
+}
+
+func TestEventBuffer(t *testing.T) {
+	config := &WatchBuffer{Size: 2, Policy: OverflowDropOldest}
+	buffer := NewEventBuffer(config)
+	assert.True(t, buffer.Push(context.Background(), "a", 1))
+	assert.True(t, buffer.Push(context.Background(), "b", 2))
+	assert.True(t, buffer.Push(context.Background(), "c", 3))
+	assert.Equal(t, []interface{}{2, 3}, readAll(buffer))
+	assert.Equal(t, uint64(1), config.Dropped())
+
+	config = &WatchBuffer{Size: 2, Policy: OverflowDropNewest}
+	buffer = NewEventBuffer(config)
+	assert.True(t, buffer.Push(context.Background(), "a", 1))
+	assert.True(t, buffer.Push(context.Background(), "b", 2))
+	assert.True(t, buffer.Push(context.Background(), "c", 3))
+	assert.Equal(t, []interface{}{1, 2}, readAll(buffer))
+	assert.Equal(t, uint64(1), config.Dropped())
+
+	config = &WatchBuffer{Size: 2, Policy: OverflowCoalesce}
+	buffer = NewEventBuffer(config)
+	assert.True(t, buffer.Push(context.Background(), "a", 1))
+	assert.True(t, buffer.Push(context.Background(), "b", 2))
+	assert.True(t, buffer.Push(context.Background(), "a", 3))
+	assert.True(t, buffer.Push(context.Background(), "c", 4))
+	assert.Equal(t, []interface{}{2, 4}, readAll(buffer))
+	assert.Equal(t, uint64(2), config.Dropped())
+
+	// Markers are never coalesced or dropped, and events are not coalesced across markers
+	config = &WatchBuffer{Size: 3, Policy: OverflowCoalesce}
+	buffer = NewEventBuffer(config)
+	assert.True(t, buffer.Push(context.Background(), "a", 1))
+	assert.True(t, buffer.Push(context.Background(), "", "synced"))
+	assert.True(t, buffer.Push(context.Background(), "b", 2))
+	assert.True(t, buffer.Push(context.Background(), "a", 3))
+	assert.True(t, buffer.Push(context.Background(), "", "resync"))
+	assert.Equal(t, []interface{}{"synced", 3, "resync"}, readAll(buffer))
+	assert.Equal(t, uint64(2), config.Dropped())
+
+	buffer = NewEventBuffer(&WatchBuffer{Size: 1, Policy: OverflowCoalesce})
+	assert.True(t, buffer.Push(context.Background(), "", "synced"))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.False(t, buffer.Push(ctx, "", "resync"))
+	assert.False(t, buffer.Push(ctx, "a", 1))
+	assert.Equal(t, []interface{}{"synced"}, readAll(buffer))
+
+	buffer = NewEventBuffer(&WatchBuffer{Size: 1, Policy: OverflowBlock})
+	assert.True(t, buffer.Push(context.Background(), "a", 1))
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.False(t, buffer.Push(ctx, "b", 2))
+
+	pushed := make(chan bool)
+	go func() {
+		pushed <- buffer.Push(context.Background(), "b", 2)
+	}()
+	event, ok := buffer.Next(context.Background())
+	assert.True(t, ok)
+	assert.Equal(t, 1, event)
+	assert.True(t, <-pushed)
+	assert.Equal(t, []interface{}{2}, readAll(buffer))
+
+	buffer = NewEventBuffer(nil)
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	_, ok = buffer.Next(ctx)
+	assert.False(t, ok)
+
+	buffer = NewEventBuffer(NewWatchBuffer(2, OverflowBlock))
+	ch := make(chan int)
+	go buffer.Forward(context.Background(), ch)
+	assert.True(t, buffer.Push(context.Background(), "a", 1))
+	assert.True(t, buffer.Push(context.Background(), "b", 2))
+	buffer.Close()
+	assert.Equal(t, 1, <-ch)
+	assert.Equal(t, 2, <-ch)
+	_, ok = <-ch
+	assert.False(t, ok)
+}
+
+type bufferOption struct {
+	*WatchBuffer
+}
+
+func TestGetWatchBuffer(t *testing.T) {
+	config := NewWatchBuffer(10, OverflowDropNewest)
+	opts := []interface{}{"foo", &bufferOption{WatchBuffer: config}}
+	assert.Equal(t, config, GetWatchBuffer(opts))
+	assert.Nil(t, GetWatchBuffer([]interface{}{"foo"}))
+}
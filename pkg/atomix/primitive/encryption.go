@@ -0,0 +1,207 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package primitive
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"github.com/atomix/atomix-go-framework/pkg/atomix/errors"
+	"io"
+	"sync"
+)
+
+const (
+	// encryptionMagic is the first byte of every encrypted value
+	encryptionMagic byte = 0xAE
+	// encryptionVersion is the version of the encrypted value header
+	encryptionVersion byte = 1
+)
+
+// KeyProvider provides the keys used to encrypt and decrypt primitive values
+// Keys must be 16, 24 or 32 bytes long to select AES-128, AES-192 or AES-256.
+type KeyProvider interface {
+	// CurrentKey returns the identifier and bytes of the key with which new values are encrypted
+	CurrentKey() (string, []byte, error)
+
+	// GetKey returns the bytes of the key with the given identifier
+	GetKey(id string) ([]byte, error)
+}
+
+// NewKeyRing creates a new KeyProvider that encrypts with the key of the given identifier
+func NewKeyRing(id string, key []byte) *KeyRing {
+	return &KeyRing{
+		current: id,
+		keys: map[string][]byte{
+			id: key,
+		},
+	}
+}
+
+// KeyRing is a KeyProvider that holds a set of keys in memory
+// Keys can be rotated by adding a new key and making it the current key. Keys that may still be used by stored
+// values must be retained in the ring until all values have been re-encrypted.
+type KeyRing struct {
+	current string
+	keys    map[string][]byte
+	mu      sync.RWMutex
+}
+
+// AddKey adds a key to the ring
+func (r *KeyRing) AddKey(id string, key []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.keys[id] = key
+}
+
+// RemoveKey removes a key from the ring
+func (r *KeyRing) RemoveKey(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if id == r.current {
+		return errors.NewInvalid("cannot remove current key '%s'", id)
+	}
+	delete(r.keys, id)
+	return nil
+}
+
+// SetCurrentKey sets the key with which new values are encrypted
+func (r *KeyRing) SetCurrentKey(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.keys[id]; !ok {
+		return errors.NewNotFound("unknown key '%s'", id)
+	}
+	r.current = id
+	return nil
+}
+
+// CurrentKey returns the current key
+func (r *KeyRing) CurrentKey() (string, []byte, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.current, r.keys[r.current], nil
+}
+
+// GetKey gets a key by identifier
+func (r *KeyRing) GetKey(id string) ([]byte, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	key, ok := r.keys[id]
+	if !ok {
+		return nil, errors.NewNotFound("unknown key '%s'", id)
+	}
+	return key, nil
+}
+
+// WithEncryption sets a key provider with which primitive values are encrypted before they're stored
+func WithEncryption(provider KeyProvider) Option {
+	return &encryptionOption{
+		provider: provider,
+	}
+}
+
+// encryptionOption is an encryption option
+type encryptionOption struct {
+	provider KeyProvider
+}
+
+func (o *encryptionOption) applyNew(options *newOptions) {
+	options.keyProvider = o.provider
+}
+
+// encrypt encrypts the given value with the current key of the given provider
+// Encrypted values are prefixed with a header containing the identifier of the key used to encrypt
+// them and the nonce:
+//   magic (1) | version (1) | key ID length (1) | key ID | nonce | AES-GCM ciphertext
+// The header is authenticated as additional data.
+func encrypt(provider KeyProvider, value []byte) ([]byte, error) {
+	id, key, err := provider.CurrentKey()
+	if err != nil {
+		return nil, err
+	}
+	if len(id) > 255 {
+		return nil, errors.NewInvalid("key ID '%s' exceeds 255 bytes", id)
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 0, 3+len(id)+gcm.NonceSize())
+	header = append(header, encryptionMagic, encryptionVersion, byte(len(id)))
+	header = append(header, id...)
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, errors.NewInternal("failed to generate nonce: %v", err)
+	}
+	header = append(header, nonce...)
+	return gcm.Seal(header, nonce, value, header), nil
+}
+
+// decrypt decrypts the given value using the key identified in the value header
+func decrypt(provider KeyProvider, value []byte) ([]byte, error) {
+	id, err := getKeyID(value)
+	if err != nil {
+		return nil, err
+	}
+	key, err := provider.GetKey(id)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	headerLen := 3 + len(id) + gcm.NonceSize()
+	if len(value) < headerLen {
+		return nil, errors.NewInvalid("encrypted value is truncated")
+	}
+	header := value[:headerLen]
+	nonce := value[3+len(id) : headerLen]
+	plaintext, err := gcm.Open(nil, nonce, value[headerLen:], header)
+	if err != nil {
+		return nil, errors.NewInvalid("failed to decrypt value with key '%s': %v", id, err)
+	}
+	return plaintext, nil
+}
+
+// getKeyID returns the identifier of the key with which the given value was encrypted
+func getKeyID(value []byte) (string, error) {
+	if len(value) < 3 || value[0] != encryptionMagic {
+		return "", errors.NewInvalid("value is not encrypted")
+	}
+	if value[1] != encryptionVersion {
+		return "", errors.NewInvalid("unknown encryption header version %d", value[1])
+	}
+	idLen := int(value[2])
+	if len(value) < 3+idLen {
+		return "", errors.NewInvalid("encrypted value is truncated")
+	}
+	return string(value[3 : 3+idLen]), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.NewInvalid("invalid encryption key: %v", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.NewInternal("failed to initialize cipher: %v", err)
+	}
+	return gcm, nil
+}
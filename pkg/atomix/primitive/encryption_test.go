@@ -0,0 +1,65 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package primitive
+
+import (
+	"github.com/atomix/atomix-go-framework/pkg/atomix/errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestEncryption(t *testing.T) {
+	keys := NewKeyRing("key-1", []byte("0123456789abcdef"))
+
+	ciphertext, err := encrypt(keys, []byte("foo"))
+	assert.NoError(t, err)
+	assert.NotEqual(t, []byte("foo"), ciphertext)
+	id, err := getKeyID(ciphertext)
+	assert.NoError(t, err)
+	assert.Equal(t, "key-1", id)
+
+	plaintext, err := decrypt(keys, ciphertext)
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", string(plaintext))
+
+	keys.AddKey("key-2", []byte("fedcba9876543210fedcba9876543210"))
+	assert.NoError(t, keys.SetCurrentKey("key-2"))
+	rotated, err := encrypt(keys, []byte("bar"))
+	assert.NoError(t, err)
+	id, err = getKeyID(rotated)
+	assert.NoError(t, err)
+	assert.Equal(t, "key-2", id)
+
+	plaintext, err = decrypt(keys, ciphertext)
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", string(plaintext))
+	plaintext, err = decrypt(keys, rotated)
+	assert.NoError(t, err)
+	assert.Equal(t, "bar", string(plaintext))
+
+	assert.Error(t, keys.RemoveKey("key-2"))
+	assert.NoError(t, keys.RemoveKey("key-1"))
+	_, err = decrypt(keys, ciphertext)
+	assert.True(t, errors.IsNotFound(err))
+
+	rotated[len(rotated)-1] ^= 0xFF
+	_, err = decrypt(keys, rotated)
+	assert.True(t, errors.IsInvalid(err))
+
+	_, err = decrypt(keys, []byte("bar"))
+	assert.True(t, errors.IsInvalid(err))
+
+	assert.True(t, errors.IsNotFound(keys.SetCurrentKey("key-3")))
+}
@@ -26,8 +26,9 @@ func (EmptyOption) applyNew(*newOptions) {}
 
 // newOptions is a set of primitive options
 type newOptions struct {
-	clusterKey string
-	sessionID  string
+	clusterKey  string
+	sessionID   string
+	keyProvider KeyProvider
 }
 
 // WithClusterKey sets the primitive cluster key
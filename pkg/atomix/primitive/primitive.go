@@ -95,6 +95,24 @@ func (c *Client) GetHeaders() primitiveapi.RequestHeaders {
 	}
 }
 
+// Encrypt encrypts the given value if encryption is enabled for the primitive
+// If encryption is not enabled, the value is returned unchanged.
+func (c *Client) Encrypt(value []byte) ([]byte, error) {
+	if c.options.keyProvider == nil {
+		return value, nil
+	}
+	return encrypt(c.options.keyProvider, value)
+}
+
+// Decrypt decrypts the given value if encryption is enabled for the primitive
+// If encryption is not enabled or the value is empty, the value is returned unchanged.
+func (c *Client) Decrypt(value []byte) ([]byte, error) {
+	if c.options.keyProvider == nil || len(value) == 0 {
+		return value, nil
+	}
+	return decrypt(c.options.keyProvider, value)
+}
+
 // Create creates an instance of the primitive
 func (c *Client) Create(ctx context.Context) error {
 	request := &primitiveapi.CreateRequest{
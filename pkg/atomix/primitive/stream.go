@@ -0,0 +1,80 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package primitive
+
+import (
+	"context"
+	"github.com/atomix/atomix-go-framework/pkg/atomix/errors"
+	"time"
+)
+
+// RecvWithContext calls the given receive function, cancelling the stream if the context is done first
+// A gRPC stream can only be cancelled through the context with which it was opened, so iterators open streams
+// with a cancellable context and pass its cancel function here to allow each call to be bounded by its own
+// context. If the context is done before recv returns, the stream is cancelled and the context error is returned.
+func RecvWithContext(ctx context.Context, cancel context.CancelFunc, recv func() error) error {
+	if ctx.Done() == nil {
+		return recv()
+	}
+	if err := ctx.Err(); err != nil {
+		cancel()
+		return errors.From(err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			cancel()
+		case <-done:
+		}
+	}()
+	err := recv()
+	close(done)
+	if err != nil && ctx.Err() != nil {
+		return errors.From(ctx.Err())
+	}
+	return err
+}
+
+const (
+	// defaultInitialRetryDelay is the initial delay between attempts to re-establish a stream
+	defaultInitialRetryDelay = 100 * time.Millisecond
+	// defaultMaxRetryDelay is the maximum delay between attempts to re-establish a stream
+	defaultMaxRetryDelay = 5 * time.Second
+)
+
+// RetryWithBackoff calls the given function until it succeeds or the context is done
+// The delay between attempts starts at 100ms and is doubled after each failed attempt up to 5s. If the
+// context is done before the function succeeds, the context error is returned.
+func RetryWithBackoff(ctx context.Context, f func() error) error {
+	delay := defaultInitialRetryDelay
+	for {
+		if err := f(); err == nil {
+			return nil
+		} else if ctx.Err() != nil {
+			return errors.From(ctx.Err())
+		}
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return errors.From(ctx.Err())
+		}
+		delay *= 2
+		if delay > defaultMaxRetryDelay {
+			delay = defaultMaxRetryDelay
+		}
+	}
+}
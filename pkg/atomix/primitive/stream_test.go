@@ -0,0 +1,63 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package primitive
+
+import (
+	"context"
+	"github.com/atomix/atomix-go-framework/pkg/atomix/errors"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestRecvWithContext(t *testing.T) {
+	streamCtx, cancel := context.WithCancel(context.Background())
+	recv := func() error {
+		<-streamCtx.Done()
+		return io.ErrUnexpectedEOF
+	}
+
+	err := RecvWithContext(context.Background(), cancel, func() error {
+		return io.EOF
+	})
+	assert.Equal(t, io.EOF, err)
+
+	ctx, cancelTimeout := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancelTimeout()
+	err = RecvWithContext(ctx, cancel, recv)
+	assert.True(t, errors.IsTimeout(err))
+	assert.Error(t, streamCtx.Err())
+}
+
+func TestRetryWithBackoff(t *testing.T) {
+	attempts := 0
+	err := RetryWithBackoff(context.Background(), func() error {
+		attempts++
+		if attempts < 3 {
+			return errors.NewUnavailable("unavailable")
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err = RetryWithBackoff(ctx, func() error {
+		return errors.NewUnavailable("unavailable")
+	})
+	assert.True(t, errors.IsTimeout(err))
+}
@@ -0,0 +1,126 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package queue
+
+import (
+	"encoding/binary"
+	"github.com/atomix/atomix-go-client/pkg/atomix/indexedmap"
+	"github.com/atomix/atomix-go-framework/pkg/atomix/errors"
+	"github.com/atomix/atomix-go-framework/pkg/atomix/meta"
+	"time"
+)
+
+// itemVersion is the version of the item encoding
+const itemVersion byte = 1
+
+const (
+	// itemFlagDead marks dead-lettered items
+	itemFlagDead byte = 1 << iota
+)
+
+// Item is an item in a queue
+type Item struct {
+	meta.ObjectMeta
+
+	// ID is the unique identifier of the item
+	ID string
+
+	// Index is the position of the item in the queue
+	Index indexedmap.Index
+
+	// Value is the item value
+	Value []byte
+
+	// Attempts is the number of times the item has been delivered
+	Attempts int
+
+	// Owner identifies the consumer to which the item is leased, if any
+	// The lease is not tied to the owner's session and is held until the Deadline.
+	Owner string
+
+	// Deadline is the time at which the lease on the item expires
+	Deadline time.Time
+
+	// Dead indicates whether the item has been dead-lettered
+	Dead bool
+}
+
+// leased returns a bool indicating whether the item is leased at the given time
+func (i *Item) leased(now time.Time) bool {
+	return i.Owner != "" && now.Before(i.Deadline)
+}
+
+// encodeItem encodes the delivery state and value of the given item
+func encodeItem(item *Item) []byte {
+	var flags byte
+	if item.Dead {
+		flags |= itemFlagDead
+	}
+	var deadline int64
+	if item.Owner != "" {
+		deadline = item.Deadline.UnixNano()
+	}
+	value := make([]byte, 0, 2+binary.MaxVarintLen64*2+8+len(item.Owner)+len(item.Value))
+	value = append(value, itemVersion, flags)
+	value = appendUvarint(value, uint64(item.Attempts))
+	value = appendUvarint(value, uint64(len(item.Owner)))
+	value = append(value, item.Owner...)
+	value = append(value, make([]byte, 8)...)
+	binary.BigEndian.PutUint64(value[len(value)-8:], uint64(deadline))
+	return append(value, item.Value...)
+}
+
+// decodeItem decodes the item stored in the given entry
+func decodeItem(entry *indexedmap.Entry) (*Item, error) {
+	value := entry.Value
+	if len(value) < 2 || value[0] != itemVersion {
+		return nil, errors.NewInvalid("entry '%s' is not a queue item", entry.Key)
+	}
+	item := &Item{
+		ObjectMeta: entry.ObjectMeta,
+		ID:         entry.Key,
+		Index:      entry.Index,
+		Dead:       value[1]&itemFlagDead != 0,
+	}
+	value = value[2:]
+
+	attempts, n := binary.Uvarint(value)
+	if n <= 0 {
+		return nil, errors.NewInvalid("entry '%s' is not a queue item", entry.Key)
+	}
+	item.Attempts = int(attempts)
+	value = value[n:]
+
+	ownerLen, n := binary.Uvarint(value)
+	if n <= 0 || uint64(len(value)-n) < ownerLen+8 {
+		return nil, errors.NewInvalid("entry '%s' is not a queue item", entry.Key)
+	}
+	value = value[n:]
+	item.Owner = string(value[:ownerLen])
+	value = value[ownerLen:]
+
+	if deadline := int64(binary.BigEndian.Uint64(value[:8])); item.Owner != "" {
+		item.Deadline = time.Unix(0, deadline)
+	}
+	item.Value = value[8:]
+	return item, nil
+}
+
+// appendUvarint appends the given value to the given bytes as a uvarint
+func appendUvarint(value []byte, i uint64) []byte {
+	buf := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutUvarint(buf, i)
+	return append(value, buf[:n]...)
+}
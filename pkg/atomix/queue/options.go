@@ -0,0 +1,70 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package queue
+
+import (
+	"github.com/atomix/atomix-go-client/pkg/atomix/primitive"
+	"time"
+)
+
+const (
+	// defaultVisibilityTimeout is the default duration for which a dequeued item is leased to the consumer
+	defaultVisibilityTimeout = 30 * time.Second
+)
+
+// Option is a queue option
+type Option interface {
+	primitive.Option
+	applyNewQueue(options *newQueueOptions)
+}
+
+// newQueueOptions is queue options
+type newQueueOptions struct {
+	visibilityTimeout time.Duration
+	maxAttempts       int
+}
+
+// WithVisibilityTimeout returns a queue option that sets the duration for which dequeued items are leased
+// Items that are not acknowledged before their lease expires are redelivered. The lease deadline is computed from
+// the clock of the consumer and checked against the clocks of other consumers, so the clocks of all clients are
+// assumed to be synchronized to well within the timeout. Clock skew shortens or extends leases, and can cause an
+// item to be delivered to a second consumer while the first is still processing it.
+func WithVisibilityTimeout(timeout time.Duration) Option {
+	return &visibilityTimeoutOption{timeout: timeout}
+}
+
+type visibilityTimeoutOption struct {
+	primitive.EmptyOption
+	timeout time.Duration
+}
+
+func (o *visibilityTimeoutOption) applyNewQueue(options *newQueueOptions) {
+	options.visibilityTimeout = o.timeout
+}
+
+// WithMaxAttempts returns a queue option that dead-letters items after the given number of delivery attempts
+// By default, items are redelivered until they're acknowledged.
+func WithMaxAttempts(attempts int) Option {
+	return &maxAttemptsOption{attempts: attempts}
+}
+
+type maxAttemptsOption struct {
+	primitive.EmptyOption
+	attempts int
+}
+
+func (o *maxAttemptsOption) applyNewQueue(options *newQueueOptions) {
+	options.maxAttempts = o.attempts
+}
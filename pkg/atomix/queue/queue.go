@@ -0,0 +1,377 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package queue
+
+import (
+	"context"
+	"github.com/atomix/atomix-go-client/pkg/atomix/indexedmap"
+	"github.com/atomix/atomix-go-client/pkg/atomix/primitive"
+	"github.com/atomix/atomix-go-framework/pkg/atomix/errors"
+	"github.com/atomix/atomix-go-framework/pkg/atomix/logging"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"io"
+	"math/rand"
+	"time"
+)
+
+var log = logging.GetLogger("atomix", "client", "queue")
+
+// maxWakeupJitter is the maximum delay before a blocked consumer rescans the queue after an item becomes available
+// Consumers blocked on the same queue are woken by the same events, so the delay spreads out their rescans.
+const maxWakeupJitter = 50 * time.Millisecond
+
+// Type is the queue type
+// Queues are stored in an IndexedMap primitive, with each item stored in an entry of the map. The IndexedMap is
+// named with MapName so that a queue does not share its items with the IndexedMap of the same name.
+const Type primitive.Type = indexedmap.Type
+
+// mapPrefix is the prefix of the name of the IndexedMap in which a queue is stored
+const mapPrefix = "queue."
+
+// MapName returns the name of the IndexedMap primitive in which the queue of the given name is stored
+func MapName(name string) string {
+	return mapPrefix + name
+}
+
+// Client provides an API for creating Queues
+type Client interface {
+	// GetQueue gets the Queue instance of the given name
+	GetQueue(ctx context.Context, name string, opts ...primitive.Option) (Queue, error)
+}
+
+// Queue is a distributed work queue with consumer acknowledgement
+// Dequeued items are leased to the consumer for the queue's visibility timeout. Items that are not acknowledged
+// before their lease expires are redelivered, so items are delivered at least once. A lease is a deadline stored
+// with the item and is not tied to the consumer's session, so the items leased by a consumer that fails are
+// redelivered only once their leases expire. Leases are timed by the clocks of the clients, which are assumed to
+// be loosely synchronized; clock skew between clients can cause an item to be delivered twice.
+type Queue interface {
+	primitive.Primitive
+
+	// Enqueue adds a value to the tail of the queue
+	Enqueue(ctx context.Context, value []byte) (*Item, error)
+
+	// Dequeue leases the item nearest the head of the queue that is not leased to another consumer
+	// If no item is available, Dequeue blocks until an item is enqueued, a lease expires, or the context is done.
+	// The item must be acknowledged with Ack once it has been processed.
+	Dequeue(ctx context.Context) (*Item, error)
+
+	// Ack acknowledges a dequeued item, removing it from the queue
+	// If the lease on the item expired and the item was redelivered, a Conflict error is returned.
+	Ack(ctx context.Context, item *Item) error
+
+	// Nack releases the lease on a dequeued item so it can be redelivered immediately
+	// If the lease on the item expired and the item was redelivered, a Conflict error is returned.
+	Nack(ctx context.Context, item *Item) error
+
+	// DeadLetters lists the items that were dead-lettered after exceeding the maximum number of delivery attempts
+	// Dead-lettered items remain in the queue until they're removed with Ack.
+	DeadLetters(ctx context.Context) ([]*Item, error)
+}
+
+// New creates a new queue
+// The queue is stored in the IndexedMap named by MapName, which must be served by the given connection.
+func New(ctx context.Context, name string, conn *grpc.ClientConn, opts ...primitive.Option) (Queue, error) {
+	options := newQueueOptions{
+		visibilityTimeout: defaultVisibilityTimeout,
+	}
+	for _, opt := range opts {
+		if op, ok := opt.(Option); ok {
+			op.applyNewQueue(&options)
+		}
+	}
+	if options.visibilityTimeout <= 0 {
+		return nil, errors.NewInvalid("visibility timeout must be positive")
+	}
+
+	m, err := indexedmap.New(ctx, MapName(name), conn, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Leases are recorded with the primitive session, or with this queue instance if no session is configured
+	// The owner only identifies the consumer holding a lease. Leases are not released when the session ends.
+	owner := uuid.New().String()
+	if session, ok := m.(interface{ SessionID() string }); ok && session.SessionID() != "" {
+		owner = session.SessionID()
+	}
+	return &queue{
+		Primitive: m,
+		m:         m,
+		owner:     owner,
+		options:   options,
+	}, nil
+}
+
+// queue is the implementation of Queue on an IndexedMap primitive
+type queue struct {
+	primitive.Primitive
+	m       indexedmap.IndexedMap
+	owner   string
+	options newQueueOptions
+}
+
+func (q *queue) Enqueue(ctx context.Context, value []byte) (*Item, error) {
+	item := &Item{
+		ID:    uuid.New().String(),
+		Value: value,
+	}
+	entry, err := q.m.Append(ctx, item.ID, encodeItem(item))
+	if err != nil {
+		return nil, err
+	}
+	item.ObjectMeta = entry.ObjectMeta
+	item.Index = entry.Index
+	return item, nil
+}
+
+func (q *queue) Dequeue(ctx context.Context) (*Item, error) {
+	// Changes to the queue are watched before it's scanned so no enqueued item is missed while waiting
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	ch := make(chan indexedmap.Event)
+	if err := q.m.Watch(watchCtx, ch); err != nil {
+		return nil, err
+	}
+
+	cursor := &scanCursor{}
+	for {
+		item, deadline, err := q.tryDequeue(ctx, cursor)
+		if err != nil {
+			return nil, err
+		} else if item != nil {
+			return item, nil
+		}
+
+		// Wait for an item to become available or for the earliest lease to expire
+		if err := q.wait(ctx, ch, cursor, deadline); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// scanCursor is the index from which a consumer scans the queue
+// The items below the cursor were found to be leased or dead-lettered by an earlier scan. The cursor is moved
+// back when an item below it is released, and is reset once the earliest lease below it expires.
+type scanCursor struct {
+	index    indexedmap.Index
+	deadline time.Time
+}
+
+// skip moves the cursor past the given unavailable item
+func (c *scanCursor) skip(item *Item) {
+	c.index = item.Index + 1
+	if item.Owner != "" && (c.deadline.IsZero() || item.Deadline.Before(c.deadline)) {
+		c.deadline = item.Deadline
+	}
+}
+
+// release moves the cursor back to the given index if it's below the cursor
+func (c *scanCursor) release(index indexedmap.Index) {
+	if index < c.index {
+		c.index = index
+	}
+}
+
+// wait waits for an item to become available or for the given deadline to pass
+// A zero deadline is never reached. Events that cannot make an item available, like leases taken by other
+// consumers and acknowledgements, are ignored. Once an item becomes available, the consumer waits a random
+// delay before returning so consumers woken by the same event don't all rescan the queue at once.
+func (q *queue) wait(ctx context.Context, ch <-chan indexedmap.Event, cursor *scanCursor, deadline time.Time) error {
+	var expired <-chan time.Time
+	if !deadline.IsZero() {
+		timer := time.NewTimer(time.Until(deadline))
+		defer timer.Stop()
+		expired = timer.C
+	}
+	var wakeup <-chan time.Time
+	for {
+		select {
+		case event, ok := <-ch:
+			if !ok {
+				if err := ctx.Err(); err != nil {
+					return errors.From(err)
+				}
+				return errors.NewUnavailable("queue watch failed")
+			}
+			if q.isAvailable(event, cursor) && wakeup == nil {
+				timer := time.NewTimer(time.Duration(rand.Int63n(int64(maxWakeupJitter))))
+				defer timer.Stop()
+				wakeup = timer.C
+			}
+		case <-wakeup:
+			return nil
+		case <-expired:
+			return nil
+		case <-ctx.Done():
+			return errors.From(ctx.Err())
+		}
+	}
+}
+
+// isAvailable returns whether the given event may have made an item available, moving the cursor back to the item
+func (q *queue) isAvailable(event indexedmap.Event, cursor *scanCursor) bool {
+	switch event.Type {
+	case indexedmap.EventInsert:
+		return true
+	case indexedmap.EventUpdate:
+		item, err := decodeItem(&event.Entry)
+		if err != nil || item.Dead || item.leased(time.Now()) {
+			return false
+		}
+		cursor.release(item.Index)
+		return true
+	case indexedmap.EventRemove:
+		return false
+	}
+	return true
+}
+
+// tryDequeue scans the queue from the given cursor and leases the first available item
+// If no item is available, the time at which the earliest lease expires is returned.
+func (q *queue) tryDequeue(ctx context.Context, cursor *scanCursor) (*Item, time.Time, error) {
+	if !cursor.deadline.IsZero() && !time.Now().Before(cursor.deadline) {
+		*cursor = scanCursor{}
+	}
+	iterator, err := q.m.Range(ctx, cursor.index, 0)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	defer iterator.Close()
+
+	// The cursor is advanced past the unavailable items at the head of the scan
+	deadline := cursor.deadline
+	head := true
+	for {
+		entry, err := iterator.Next(ctx)
+		if err == io.EOF {
+			return nil, deadline, nil
+		} else if err != nil {
+			return nil, time.Time{}, err
+		}
+
+		item, err := decodeItem(&entry)
+		if err != nil {
+			log.Warnf("Skipping entry %d: %v", entry.Index, err)
+			if head {
+				cursor.index = entry.Index + 1
+			}
+			continue
+		}
+		if item.Dead {
+			if head {
+				cursor.skip(item)
+			}
+			continue
+		}
+
+		now := time.Now()
+		if item.leased(now) {
+			if deadline.IsZero() || item.Deadline.Before(deadline) {
+				deadline = item.Deadline
+			}
+			if head {
+				cursor.skip(item)
+			}
+			continue
+		}
+
+		if q.options.maxAttempts > 0 && item.Attempts >= q.options.maxAttempts {
+			if err := q.deadLetter(ctx, item); err != nil {
+				return nil, time.Time{}, err
+			}
+			head = false
+			continue
+		}
+
+		item.Attempts++
+		item.Owner = q.owner
+		item.Deadline = now.Add(q.options.visibilityTimeout)
+		updated, err := q.m.Set(ctx, item.Index, item.ID, encodeItem(item), indexedmap.IfMatch(item))
+		if err != nil {
+			if errors.IsConflict(err) || errors.IsNotFound(err) {
+				// The item was leased or acknowledged by another consumer
+				head = false
+				continue
+			}
+			return nil, time.Time{}, err
+		}
+		item.ObjectMeta = updated.ObjectMeta
+		return item, time.Time{}, nil
+	}
+}
+
+// deadLetter marks the given item as dead-lettered
+func (q *queue) deadLetter(ctx context.Context, item *Item) error {
+	log.Warnf("Dead-lettering item %s after %d attempts", item.ID, item.Attempts)
+	item.Dead = true
+	item.Owner = ""
+	_, err := q.m.Set(ctx, item.Index, item.ID, encodeItem(item), indexedmap.IfMatch(item))
+	if err != nil && !errors.IsConflict(err) && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+func (q *queue) Ack(ctx context.Context, item *Item) error {
+	_, err := q.m.RemoveIndex(ctx, item.Index, indexedmap.IfMatch(item))
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return errors.NewConflict("item %s is no longer leased", item.ID)
+		}
+		return err
+	}
+	return nil
+}
+
+func (q *queue) Nack(ctx context.Context, item *Item) error {
+	released := *item
+	released.Owner = ""
+	released.Deadline = time.Time{}
+	_, err := q.m.Set(ctx, item.Index, item.ID, encodeItem(&released), indexedmap.IfMatch(item))
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return errors.NewConflict("item %s is no longer leased", item.ID)
+		}
+		return err
+	}
+	return nil
+}
+
+func (q *queue) DeadLetters(ctx context.Context) ([]*Item, error) {
+	iterator, err := q.m.Iterate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer iterator.Close()
+
+	items := make([]*Item, 0)
+	for {
+		entry, err := iterator.Next(ctx)
+		if err == io.EOF {
+			return items, nil
+		} else if err != nil {
+			return nil, err
+		}
+		item, err := decodeItem(&entry)
+		if err != nil {
+			continue
+		}
+		if item.Dead {
+			items = append(items, item)
+		}
+	}
+}
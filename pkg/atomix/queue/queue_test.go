@@ -0,0 +1,292 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package queue
+
+import (
+	"context"
+	primitiveapi "github.com/atomix/atomix-api/go/atomix/primitive"
+	"github.com/atomix/atomix-go-client/pkg/atomix/indexedmap"
+	"github.com/atomix/atomix-go-client/pkg/atomix/util/test"
+	"github.com/atomix/atomix-go-framework/pkg/atomix/errors"
+	"github.com/atomix/atomix-go-framework/pkg/atomix/logging"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestQueueOperations(t *testing.T) {
+	logging.SetLevel(logging.DebugLevel)
+
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      MapName("TestQueueOperations"),
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn1, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	conn2, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	q1, err := New(context.TODO(), "TestQueueOperations", conn1, WithVisibilityTimeout(500*time.Millisecond))
+	assert.NoError(t, err)
+
+	q2, err := New(context.TODO(), "TestQueueOperations", conn2, WithVisibilityTimeout(500*time.Millisecond))
+	assert.NoError(t, err)
+
+	for _, value := range []string{"foo", "bar", "baz"} {
+		_, err = q1.Enqueue(context.TODO(), []byte(value))
+		assert.NoError(t, err)
+	}
+
+	foo, err := q1.Dequeue(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", string(foo.Value))
+	assert.Equal(t, 1, foo.Attempts)
+	assert.NoError(t, q1.Ack(context.TODO(), foo))
+
+	// Leased items are not delivered to other consumers
+	bar, err := q1.Dequeue(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, "bar", string(bar.Value))
+	baz, err := q2.Dequeue(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, "baz", string(baz.Value))
+
+	// Nacked items are redelivered immediately
+	assert.NoError(t, q2.Nack(context.TODO(), baz))
+	baz, err = q2.Dequeue(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, "baz", string(baz.Value))
+	assert.Equal(t, 2, baz.Attempts)
+	assert.NoError(t, q2.Ack(context.TODO(), baz))
+
+	// Items are redelivered once their lease expires
+	redelivered, err := q2.Dequeue(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, "bar", string(redelivered.Value))
+	assert.Equal(t, 2, redelivered.Attempts)
+	assert.True(t, time.Now().After(bar.Deadline))
+
+	// Acknowledging an item after its lease was taken over fails
+	err = q1.Ack(context.TODO(), bar)
+	assert.True(t, errors.IsConflict(err))
+	assert.NoError(t, q2.Ack(context.TODO(), redelivered))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	_, err = q1.Dequeue(ctx)
+	cancel()
+	assert.True(t, errors.IsTimeout(err) || errors.IsCanceled(err))
+
+	assert.NoError(t, test.Stop())
+}
+
+func TestQueueBlockingDequeue(t *testing.T) {
+	logging.SetLevel(logging.DebugLevel)
+
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      MapName("TestQueueBlockingDequeue"),
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn1, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	conn2, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	q1, err := New(context.TODO(), "TestQueueBlockingDequeue", conn1)
+	assert.NoError(t, err)
+
+	q2, err := New(context.TODO(), "TestQueueBlockingDequeue", conn2)
+	assert.NoError(t, err)
+
+	itemCh := make(chan *Item)
+	go func() {
+		item, err := q1.Dequeue(context.Background())
+		assert.NoError(t, err)
+		itemCh <- item
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	_, err = q2.Enqueue(context.TODO(), []byte("foo"))
+	assert.NoError(t, err)
+
+	select {
+	case item := <-itemCh:
+		assert.Equal(t, "foo", string(item.Value))
+		assert.NoError(t, q1.Ack(context.TODO(), item))
+	case <-time.After(5 * time.Second):
+		t.Fatal("dequeue was not unblocked by enqueue")
+	}
+
+	// A blocked consumer scans past leased items, and is unblocked when an item it skipped is released
+	_, err = q2.Enqueue(context.TODO(), []byte("bar"))
+	assert.NoError(t, err)
+	_, err = q2.Enqueue(context.TODO(), []byte("baz"))
+	assert.NoError(t, err)
+	bar, err := q2.Dequeue(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, "bar", string(bar.Value))
+	baz, err := q2.Dequeue(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, "baz", string(baz.Value))
+
+	go func() {
+		item, err := q1.Dequeue(context.Background())
+		assert.NoError(t, err)
+		itemCh <- item
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	assert.NoError(t, q2.Nack(context.TODO(), bar))
+
+	select {
+	case item := <-itemCh:
+		assert.Equal(t, "bar", string(item.Value))
+		assert.Equal(t, 2, item.Attempts)
+	case <-time.After(5 * time.Second):
+		t.Fatal("dequeue was not unblocked by nack")
+	}
+
+	assert.NoError(t, test.Stop())
+}
+
+func TestQueueWakeup(t *testing.T) {
+	q := &queue{}
+	cursor := &scanCursor{index: 10}
+
+	// Leases taken by other consumers and acknowledgements don't make items available
+	leased := &Item{ID: "foo", Index: 3, Owner: "bar", Deadline: time.Now().Add(time.Minute)}
+	event := indexedmap.Event{Type: indexedmap.EventUpdate, Entry: indexedmap.Entry{Index: 3, Key: "foo", Value: encodeItem(leased)}}
+	assert.False(t, q.isAvailable(event, cursor))
+	event.Type = indexedmap.EventRemove
+	assert.False(t, q.isAvailable(event, cursor))
+	assert.Equal(t, indexedmap.Index(10), cursor.index)
+
+	// Released items move the cursor back
+	released := &Item{ID: "foo", Index: 3}
+	event = indexedmap.Event{Type: indexedmap.EventUpdate, Entry: indexedmap.Entry{Index: 3, Key: "foo", Value: encodeItem(released)}}
+	assert.True(t, q.isAvailable(event, cursor))
+	assert.Equal(t, indexedmap.Index(3), cursor.index)
+
+	assert.True(t, q.isAvailable(indexedmap.Event{Type: indexedmap.EventInsert}, cursor))
+}
+
+func TestQueueDeadLetters(t *testing.T) {
+	logging.SetLevel(logging.DebugLevel)
+
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      MapName("TestQueueDeadLetters"),
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	q, err := New(context.TODO(), "TestQueueDeadLetters", conn, WithMaxAttempts(2))
+	assert.NoError(t, err)
+
+	_, err = q.Enqueue(context.TODO(), []byte("poison"))
+	assert.NoError(t, err)
+	_, err = q.Enqueue(context.TODO(), []byte("foo"))
+	assert.NoError(t, err)
+
+	for i := 1; i <= 2; i++ {
+		item, err := q.Dequeue(context.TODO())
+		assert.NoError(t, err)
+		assert.Equal(t, "poison", string(item.Value))
+		assert.Equal(t, i, item.Attempts)
+		assert.NoError(t, q.Nack(context.TODO(), item))
+	}
+
+	item, err := q.Dequeue(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", string(item.Value))
+	assert.NoError(t, q.Ack(context.TODO(), item))
+
+	items, err := q.DeadLetters(context.TODO())
+	assert.NoError(t, err)
+	assert.Len(t, items, 1)
+	assert.Equal(t, "poison", string(items[0].Value))
+	assert.True(t, items[0].Dead)
+	assert.NoError(t, q.Ack(context.TODO(), items[0]))
+
+	items, err = q.DeadLetters(context.TODO())
+	assert.NoError(t, err)
+	assert.Len(t, items, 0)
+
+	assert.NoError(t, test.Stop())
+}
+
+func TestQueueName(t *testing.T) {
+	logging.SetLevel(logging.DebugLevel)
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	// A queue does not share its items with the IndexedMap of the same name
+	queueConn, err := test.CreateProxy(primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      MapName("TestQueueName"),
+	})
+	assert.NoError(t, err)
+	mapConn, err := test.CreateProxy(primitiveapi.PrimitiveId{
+		Type:      indexedmap.Type.String(),
+		Namespace: "test",
+		Name:      "TestQueueName",
+	})
+	assert.NoError(t, err)
+
+	q, err := New(context.TODO(), "TestQueueName", queueConn)
+	assert.NoError(t, err)
+	m, err := indexedmap.New(context.TODO(), "TestQueueName", mapConn)
+	assert.NoError(t, err)
+
+	_, err = m.Append(context.TODO(), "foo", []byte("bar"))
+	assert.NoError(t, err)
+	_, err = q.Enqueue(context.TODO(), []byte("baz"))
+	assert.NoError(t, err)
+
+	size, err := m.Len(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, size)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	item, err := q.Dequeue(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "baz", string(item.Value))
+	assert.NoError(t, q.Ack(context.TODO(), item))
+
+	entry, err := m.Get(context.TODO(), "foo")
+	assert.NoError(t, err)
+	assert.Equal(t, "bar", string(entry.Value))
+
+	assert.NoError(t, test.Stop())
+}
@@ -0,0 +1,98 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package set
+
+import (
+	"context"
+	api "github.com/atomix/atomix-api/go/atomix/primitive/set"
+	"github.com/atomix/atomix-go-client/pkg/atomix/primitive"
+	"github.com/atomix/atomix-go-framework/pkg/atomix/errors"
+	"io"
+)
+
+// ElementIterator iterates over the elements in a set
+type ElementIterator interface {
+	// Next returns the next element
+	// Once all elements have been read, io.EOF is returned. If the iteration fails, the error is returned
+	// and all subsequent calls return the same error. If the context is done before the next element is
+	// read, the iteration is aborted and the context error is returned.
+	Next(ctx context.Context) (string, error)
+
+	// Close closes the iterator, cancelling the underlying stream
+	Close()
+}
+
+// elementIterator is an ElementIterator that reads elements from a server stream
+type elementIterator struct {
+	stream api.SetService_ElementsClient
+	cancel context.CancelFunc
+	decode func(response *api.ElementsResponse) (string, error)
+	err    error
+}
+
+func (i *elementIterator) Next(ctx context.Context) (string, error) {
+	if i.err != nil {
+		return "", i.err
+	}
+	var response *api.ElementsResponse
+	err := primitive.RecvWithContext(ctx, i.cancel, func() error {
+		var err error
+		response, err = i.stream.Recv()
+		return err
+	})
+	if err == nil {
+		var element string
+		element, err = i.decode(response)
+		if err == nil {
+			return element, nil
+		}
+	}
+	if err != io.EOF {
+		err = errors.From(err)
+	}
+	i.err = err
+	i.cancel()
+	return "", err
+}
+
+func (i *elementIterator) Close() {
+	if i.err == nil {
+		i.err = errors.NewCanceled("iterator is closed")
+	}
+	i.cancel()
+}
+
+// pushElements pushes the elements read from the given iterator onto the given channel
+// The channel is closed once all elements have been read, the iteration fails, or the context is done.
+func pushElements(ctx context.Context, iterator ElementIterator, ch chan<- string) {
+	defer close(ch)
+	defer iterator.Close()
+	for {
+		element, err := iterator.Next(ctx)
+		if err == io.EOF {
+			return
+		} else if err != nil {
+			if !errors.IsCanceled(err) && !errors.IsTimeout(err) {
+				log.Errorf("Elements failed: %v", err)
+			}
+			return
+		}
+		select {
+		case ch <- element:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
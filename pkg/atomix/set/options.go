@@ -48,3 +48,50 @@ func (o replayOption) beforeWatch(request *api.EventsRequest) {
 func (o replayOption) afterWatch(response *api.EventsResponse) {
 
 }
+
+// WithBuffer returns a watch option that buffers events between the watch stream and the channel
+// See primitive.WatchBuffer.
+func WithBuffer(size int, policy primitive.OverflowPolicy) *BufferOption {
+	return &BufferOption{
+		WatchBuffer: primitive.NewWatchBuffer(size, policy),
+	}
+}
+
+// BufferOption is a watch option that configures the buffering of watch events
+type BufferOption struct {
+	*primitive.WatchBuffer
+}
+
+func (o *BufferOption) beforeWatch(request *api.EventsRequest) {
+}
+
+func (o *BufferOption) afterWatch(response *api.EventsResponse) {
+}
+
+// WithAutoReconnect returns a watch option that re-establishes the watch stream when it fails
+// The stream is re-opened with backoff until the watch context is done. Because the watch cannot be resumed
+// where it left off, the client tracks the elements in the set and, once the stream is re-opened, reads the
+// set and sends an event for each element that was added or removed while the stream was down. Events that
+// were already delivered are not repeated.
+// An EventResync event is sent once the stream is re-opened, before the events for the missed changes.
+func WithAutoReconnect() WatchOption {
+	return reconnectOption{}
+}
+
+type reconnectOption struct{}
+
+func (o reconnectOption) beforeWatch(request *api.EventsRequest) {
+}
+
+func (o reconnectOption) afterWatch(response *api.EventsResponse) {
+}
+
+// isAutoReconnect returns a bool indicating whether the given watch options enable auto-reconnect
+func isAutoReconnect(opts []WatchOption) bool {
+	for i := range opts {
+		if _, ok := opts[i].(reconnectOption); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -57,6 +57,10 @@ type Set interface {
 	// Clear removes all values from the set
 	Clear(ctx context.Context) error
 
+	// Iterate returns an iterator over the elements in the set
+	// The iterator must be closed once the caller is done with it.
+	Iterate(ctx context.Context) (ElementIterator, error)
+
 	// Elements lists the elements in the set
 	Elements(ctx context.Context, ch chan<- string) error
 
@@ -78,6 +82,14 @@ const (
 
 	// EventReplay indicates a value was replayed
 	EventReplay EventType = "replay"
+
+	// EventSynced indicates all the values in the set have been replayed
+	EventSynced EventType = "synced"
+
+	// EventResync indicates the watch stream was re-established and events may have been missed
+	// A resync event carries no element, and is followed by an event for each element that was added or
+	// removed while the stream was down.
+	EventResync EventType = "resync"
 )
 
 // Event is a set change event
@@ -186,28 +198,31 @@ func (s *set) Clear(ctx context.Context) error {
 	return nil
 }
 
-func (s *set) Elements(ctx context.Context, ch chan<- string) error {
+func (s *set) Iterate(ctx context.Context) (ElementIterator, error) {
 	request := &api.ElementsRequest{
 		Headers: s.GetHeaders(),
 	}
+	ctx, cancel := context.WithCancel(ctx)
 	stream, err := s.client.Elements(ctx, request)
 	if err != nil {
-		return errors.From(err)
+		cancel()
+		return nil, errors.From(err)
 	}
+	return &elementIterator{
+		stream: stream,
+		cancel: cancel,
+		decode: func(response *api.ElementsResponse) (string, error) {
+			return response.Element.Value, nil
+		},
+	}, nil
+}
 
-	go func() {
-		defer close(ch)
-		for {
-			response, err := stream.Recv()
-			if err == io.EOF {
-				return
-			} else if err != nil {
-				log.Errorf("Elements failed: %v", err)
-			} else {
-				ch <- response.Element.Value
-			}
-		}
-	}()
+func (s *set) Elements(ctx context.Context, ch chan<- string) error {
+	iterator, err := s.Iterate(ctx)
+	if err != nil {
+		return err
+	}
+	go pushElements(ctx, iterator, ch)
 	return nil
 }
 
@@ -219,20 +234,60 @@ func (s *set) Watch(ctx context.Context, ch chan<- Event, opts ...WatchOption) e
 		opts[i].beforeWatch(request)
 	}
 
+	// Replay is performed by the client so the end of the replay can be signaled to the consumer
+	replay := request.Replay
+	request.Replay = false
+
 	stream, err := s.client.Events(ctx, request)
 	if err != nil {
 		return errors.From(err)
 	}
 
+	// To resynchronize a watch after the stream is re-established, the elements in the set must be tracked
+	// Replayed elements are tracked to discard events for changes already reflected in the replay.
+	var tracker *elementTracker
+	if replay {
+		tracker = newElementTracker()
+	} else if isAutoReconnect(opts) {
+		tracker = newElementTracker()
+		elements, err := s.readElements(ctx)
+		if err != nil {
+			return err
+		}
+		for _, element := range elements {
+			tracker.update(Event{Type: EventReplay, Value: element})
+		}
+	}
+
+	buffer := primitive.NewEventBuffer(primitive.GetWatchBuffer(opts))
+	go buffer.Forward(ctx, ch)
+
+	send := func(event Event) bool {
+		if event.Type == EventResync {
+			return buffer.Push(ctx, "", event)
+		}
+		return buffer.Push(ctx, event.Value, event)
+	}
+	push := func(event Event) bool {
+		if tracker != nil && !tracker.update(event) {
+			return true
+		}
+		return send(event)
+	}
+
 	openCh := make(chan struct{})
 	go func() {
-		defer close(ch)
+		defer buffer.Close()
 		open := false
 		defer func() {
 			if !open {
 				close(openCh)
 			}
 		}()
+		cancel := func() {}
+		defer func() {
+			cancel()
+		}()
 		for {
 			response, err := stream.Recv()
 			if err == io.EOF ||
@@ -240,33 +295,40 @@ func (s *set) Watch(ctx context.Context, ch chan<- Event, opts ...WatchOption) e
 				errors.IsTimeout(errors.From(err)) {
 				return
 			} else if err != nil {
-				log.Errorf("Watch failed: %v", err)
-				return
+				if tracker == nil || !isAutoReconnect(opts) {
+					log.Errorf("Watch failed: %v", err)
+					return
+				}
+				log.Warnf("Watch failed; reconnecting: %v", err)
+				cancel()
+				stream, cancel, err = s.resync(ctx, request, tracker, send)
+				if err != nil {
+					cancel = func() {}
+					return
+				}
 			} else {
 				if !open {
 					close(openCh)
 					open = true
+					if replay {
+						if err := s.replay(ctx, tracker, send); err != nil {
+							if ctx.Err() == nil {
+								log.Errorf("Watch failed: %v", err)
+							}
+							return
+						}
+						if !buffer.Push(ctx, "", Event{Type: EventSynced}) {
+							return
+						}
+					}
 				}
 				for i := range opts {
 					opts[i].afterWatch(response)
 				}
 
-				switch response.Event.Type {
-				case api.Event_ADD:
-					ch <- Event{
-						Type:  EventAdd,
-						Value: response.Event.Element.Value,
-					}
-				case api.Event_REMOVE:
-					ch <- Event{
-						Type:  EventRemove,
-						Value: response.Event.Element.Value,
-					}
-				case api.Event_REPLAY:
-					ch <- Event{
-						Type:  EventReplay,
-						Value: response.Event.Element.Value,
-					}
+				event := newEvent(&response.Event)
+				if event != nil && !push(*event) {
+					return
 				}
 			}
 		}
@@ -279,3 +341,22 @@ func (s *set) Watch(ctx context.Context, ch chan<- Event, opts ...WatchOption) e
 		return ctx.Err()
 	}
 }
+
+// newEvent converts the given event to an Event, returning nil if the event is not a change event
+func newEvent(event *api.Event) *Event {
+	var eventType EventType
+	switch event.Type {
+	case api.Event_ADD:
+		eventType = EventAdd
+	case api.Event_REMOVE:
+		eventType = EventRemove
+	case api.Event_REPLAY:
+		eventType = EventReplay
+	default:
+		return nil
+	}
+	return &Event{
+		Type:  eventType,
+		Value: event.Element.Value,
+	}
+}
@@ -21,6 +21,8 @@ import (
 	"github.com/atomix/atomix-go-framework/pkg/atomix/errors"
 	"github.com/atomix/atomix-go-framework/pkg/atomix/logging"
 	"github.com/stretchr/testify/assert"
+	"io"
+	"strconv"
 	"testing"
 )
 
@@ -124,6 +126,9 @@ func TestSetOperations(t *testing.T) {
 		assert.Equal(t, EventReplay, event.Type)
 		assert.Contains(t, []string{"foo", "bar", "baz"}, event.Value)
 
+		event = <-events
+		assert.Equal(t, EventSynced, event.Type)
+
 		done <- true
 
 		event = <-events
@@ -193,3 +198,106 @@ func TestSetOperations(t *testing.T) {
 
 	assert.NoError(t, test.Stop())
 }
+
+func TestSetIterator(t *testing.T) {
+	logging.SetLevel(logging.DebugLevel)
+
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestSetIterator",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	set, err := New(context.TODO(), "TestSetIterator", conn)
+	assert.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		_, err = set.Add(context.Background(), strconv.Itoa(i))
+		assert.NoError(t, err)
+	}
+
+	iterator, err := set.Iterate(context.Background())
+	assert.NoError(t, err)
+	elements := make([]string, 0)
+	for {
+		element, err := iterator.Next(context.Background())
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		elements = append(elements, element)
+	}
+	assert.ElementsMatch(t, []string{"0", "1", "2"}, elements)
+	_, err = iterator.Next(context.Background())
+	assert.Equal(t, io.EOF, err)
+	iterator.Close()
+
+	iterator, err = set.Iterate(context.Background())
+	assert.NoError(t, err)
+	_, err = iterator.Next(context.Background())
+	assert.NoError(t, err)
+	iterator.Close()
+	_, err = iterator.Next(context.Background())
+	assert.True(t, errors.IsCanceled(err))
+
+	iterator, err = set.Iterate(context.Background())
+	assert.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = iterator.Next(ctx)
+	assert.True(t, errors.IsCanceled(err))
+	_, err = iterator.Next(context.Background())
+	assert.True(t, errors.IsCanceled(err))
+	iterator.Close()
+
+	assert.NoError(t, test.Stop())
+}
+
+func TestSetWatchResync(t *testing.T) {
+	logging.SetLevel(logging.DebugLevel)
+
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestSetWatchResync",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	set, err := New(context.TODO(), "TestSetWatchResync", conn)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := make(chan Event)
+	err = set.Watch(ctx, ch, WithAutoReconnect())
+	assert.NoError(t, err)
+
+	_, err = set.Add(context.Background(), "foo")
+	assert.NoError(t, err)
+	event := <-ch
+	assert.Equal(t, EventAdd, event.Type)
+
+	// A broken stream is re-established and signaled with a resync event
+	test.BreakStreams()
+	event = <-ch
+	assert.Equal(t, EventResync, event.Type)
+
+	_, err = set.Add(context.Background(), "bar")
+	assert.NoError(t, err)
+	event = <-ch
+	assert.Equal(t, EventAdd, event.Type)
+	assert.Equal(t, "bar", event.Value)
+
+	assert.NoError(t, test.Stop())
+}
@@ -0,0 +1,156 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package set
+
+import (
+	"context"
+	api "github.com/atomix/atomix-api/go/atomix/primitive/set"
+	"github.com/atomix/atomix-go-client/pkg/atomix/primitive"
+	"io"
+)
+
+func newElementTracker() *elementTracker {
+	return &elementTracker{
+		elements: make(map[string]bool),
+	}
+}
+
+// elementTracker tracks the elements in the set as observed by a watch
+// Once the watch has been resynchronized, the tracked elements are exactly the state delivered to the
+// consumer, and events already reflected in that state are discarded as duplicates.
+type elementTracker struct {
+	elements map[string]bool
+	dedupe   bool
+}
+
+// update updates the tracked elements with the given event, returning false if the event is a duplicate
+func (t *elementTracker) update(event Event) bool {
+	_, ok := t.elements[event.Value]
+	switch event.Type {
+	case EventRemove:
+		if !ok && t.dedupe {
+			return false
+		}
+		delete(t.elements, event.Value)
+	default:
+		if ok && t.dedupe {
+			return false
+		}
+		t.elements[event.Value] = true
+	}
+	return true
+}
+
+// readElements reads all the elements in the set
+func (s *set) readElements(ctx context.Context) ([]string, error) {
+	iterator, err := s.Iterate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer iterator.Close()
+	elements := make([]string, 0)
+	for {
+		element, err := iterator.Next(ctx)
+		if err == io.EOF {
+			return elements, nil
+		} else if err != nil {
+			return nil, err
+		}
+		elements = append(elements, element)
+	}
+}
+
+// replay sends the elements in the set as EventReplay events
+// The elements are read once the watch stream is open, so changes made after the elements are read are
+// delivered by the stream. The replayed elements are tracked to discard events for changes made before
+// they were read.
+func (s *set) replay(ctx context.Context, tracker *elementTracker, send func(Event) bool) error {
+	elements, err := s.readElements(ctx)
+	if err != nil {
+		return err
+	}
+	for _, element := range elements {
+		tracker.elements[element] = true
+		if !send(Event{Type: EventReplay, Value: element}) {
+			return ctx.Err()
+		}
+	}
+	tracker.dedupe = true
+	return nil
+}
+
+// resync re-establishes a failed watch stream
+// The stream is re-opened and the elements in the set are compared to the tracked elements to send the
+// elements that were added or removed while the stream was down. An element that was removed and re-added
+// while the stream was down produces no event.
+// An EventResync event is sent before any of these events.
+// The new stream and a function to cancel it are returned once the watch has been resynchronized.
+func (s *set) resync(ctx context.Context, request *api.EventsRequest, tracker *elementTracker, send func(Event) bool) (api.SetService_EventsClient, context.CancelFunc, error) {
+	request = &api.EventsRequest{
+		Headers: request.Headers,
+	}
+
+	var stream api.SetService_EventsClient
+	var cancel context.CancelFunc
+	var elements []string
+	err := primitive.RetryWithBackoff(ctx, func() error {
+		var streamCtx context.Context
+		streamCtx, cancel = context.WithCancel(ctx)
+		var err error
+		stream, err = s.client.Events(streamCtx, request)
+		if err != nil {
+			cancel()
+			log.Warnf("Failed to reconnect watch: %v", err)
+			return err
+		}
+		elements, err = s.readElements(ctx)
+		if err != nil {
+			cancel()
+			log.Warnf("Failed to resynchronize watch: %v", err)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !send(Event{Type: EventResync}) {
+		cancel()
+		return nil, nil, ctx.Err()
+	}
+
+	present := make(map[string]bool)
+	for _, element := range elements {
+		present[element] = true
+		if !tracker.elements[element] {
+			if !send(Event{Type: EventAdd, Value: element}) {
+				cancel()
+				return nil, nil, ctx.Err()
+			}
+		}
+	}
+	for element := range tracker.elements {
+		if !present[element] {
+			if !send(Event{Type: EventRemove, Value: element}) {
+				cancel()
+				return nil, nil, ctx.Err()
+			}
+		}
+	}
+	tracker.elements = present
+	tracker.dedupe = true
+	return stream, cancel, nil
+}
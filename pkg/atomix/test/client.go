@@ -22,8 +22,11 @@ import (
 	"github.com/atomix/atomix-go-client/pkg/atomix/list"
 	"github.com/atomix/atomix-go-client/pkg/atomix/lock"
 	_map "github.com/atomix/atomix-go-client/pkg/atomix/map"
+	"github.com/atomix/atomix-go-client/pkg/atomix/multimap"
 	"github.com/atomix/atomix-go-client/pkg/atomix/primitive"
+	"github.com/atomix/atomix-go-client/pkg/atomix/queue"
 	"github.com/atomix/atomix-go-client/pkg/atomix/set"
+	"github.com/atomix/atomix-go-client/pkg/atomix/treemap"
 	"github.com/atomix/atomix-go-client/pkg/atomix/value"
 	"google.golang.org/grpc"
 )
@@ -102,6 +105,22 @@ func (c *testClient) GetMap(ctx context.Context, name string, opts ...primitive.
 	return _map.New(ctx, name, conn, c.getOpts(opts...)...)
 }
 
+func (c *testClient) GetMultimap(ctx context.Context, name string, opts ...primitive.Option) (multimap.Multimap, error) {
+	conn, err := c.Connect(ctx, multimap.Type, multimap.MapName(name))
+	if err != nil {
+		return nil, err
+	}
+	return multimap.New(ctx, name, conn, c.getOpts(opts...)...)
+}
+
+func (c *testClient) GetQueue(ctx context.Context, name string, opts ...primitive.Option) (queue.Queue, error) {
+	conn, err := c.Connect(ctx, queue.Type, queue.MapName(name))
+	if err != nil {
+		return nil, err
+	}
+	return queue.New(ctx, name, conn, c.getOpts(opts...)...)
+}
+
 func (c *testClient) GetSet(ctx context.Context, name string, opts ...primitive.Option) (set.Set, error) {
 	conn, err := c.Connect(ctx, set.Type, name)
 	if err != nil {
@@ -110,6 +129,14 @@ func (c *testClient) GetSet(ctx context.Context, name string, opts ...primitive.
 	return set.New(ctx, name, conn, c.getOpts(opts...)...)
 }
 
+func (c *testClient) GetTreeMap(ctx context.Context, name string, opts ...primitive.Option) (treemap.TreeMap, error) {
+	conn, err := c.Connect(ctx, treemap.Type, treemap.MapName(name))
+	if err != nil {
+		return nil, err
+	}
+	return treemap.New(ctx, name, conn, c.getOpts(opts...)...)
+}
+
 func (c *testClient) GetValue(ctx context.Context, name string, opts ...primitive.Option) (value.Value, error) {
 	conn, err := c.Connect(ctx, value.Type, name)
 	if err != nil {
@@ -0,0 +1,82 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package treemap
+
+// SubMapOption is an option for SubMap calls
+type SubMapOption interface {
+	applySubMap(options *subMapOptions)
+}
+
+// subMapOptions is sub map options
+type subMapOptions struct {
+	exclusiveStart bool
+	inclusiveEnd   bool
+	descending     bool
+	limit          int
+}
+
+func newSubMapOptions(opts ...SubMapOption) subMapOptions {
+	options := subMapOptions{}
+	for _, opt := range opts {
+		opt.applySubMap(&options)
+	}
+	return options
+}
+
+// WithExclusiveStart returns a sub map option that excludes the start key from the range
+func WithExclusiveStart() SubMapOption {
+	return exclusiveStartOption{}
+}
+
+type exclusiveStartOption struct{}
+
+func (o exclusiveStartOption) applySubMap(options *subMapOptions) {
+	options.exclusiveStart = true
+}
+
+// WithInclusiveEnd returns a sub map option that includes the end key in the range
+func WithInclusiveEnd() SubMapOption {
+	return inclusiveEndOption{}
+}
+
+type inclusiveEndOption struct{}
+
+func (o inclusiveEndOption) applySubMap(options *subMapOptions) {
+	options.inclusiveEnd = true
+}
+
+// WithDescending returns a sub map option that iterates the range in descending key order
+func WithDescending() SubMapOption {
+	return descendingOption{}
+}
+
+type descendingOption struct{}
+
+func (o descendingOption) applySubMap(options *subMapOptions) {
+	options.descending = true
+}
+
+// WithLimit returns a sub map option that returns at most the given number of entries
+func WithLimit(limit int) SubMapOption {
+	return limitOption{limit: limit}
+}
+
+type limitOption struct {
+	limit int
+}
+
+func (o limitOption) applySubMap(options *subMapOptions) {
+	options.limit = o.limit
+}
@@ -0,0 +1,271 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package treemap
+
+import (
+	"context"
+	_map "github.com/atomix/atomix-go-client/pkg/atomix/map"
+	"github.com/atomix/atomix-go-client/pkg/atomix/primitive"
+	"github.com/atomix/atomix-go-framework/pkg/atomix/errors"
+	"google.golang.org/grpc"
+	"io"
+	"math"
+	"sort"
+)
+
+// Type is the tree map type
+// Tree maps are stored in a Map primitive until the server supports a tree map protocol. The Map is named with
+// MapName so that a tree map does not share its entries with the Map of the same name.
+const Type primitive.Type = _map.Type
+
+// mapPrefix is the prefix of the name of the Map in which a tree map is stored
+const mapPrefix = "treemap."
+
+// MapName returns the name of the Map primitive in which the tree map of the given name is stored
+func MapName(name string) string {
+	return mapPrefix + name
+}
+
+// Client provides an API for creating TreeMaps
+type Client interface {
+	// GetTreeMap gets the TreeMap instance of the given name
+	GetTreeMap(ctx context.Context, name string, opts ...primitive.Option) (TreeMap, error)
+}
+
+// TreeMap is a distributed map that orders its entries by key
+// TreeMap is a local stand-in for a key-ordered map, intended for tests until the server supports a tree map
+// protocol. The map is ordered by the client, so Entries, Keys, Iterate, SubMap and every navigation method
+// stream and sort all the entries in the map. Navigation methods return a NotFound error if no entry matches
+// the query.
+type TreeMap interface {
+	_map.Map
+
+	// FirstEntry returns the entry with the lowest key in the map
+	FirstEntry(ctx context.Context) (*_map.Entry, error)
+
+	// LastEntry returns the entry with the highest key in the map
+	LastEntry(ctx context.Context) (*_map.Entry, error)
+
+	// FloorEntry returns the entry with the highest key less than or equal to the given key
+	FloorEntry(ctx context.Context, key string) (*_map.Entry, error)
+
+	// CeilingEntry returns the entry with the lowest key greater than or equal to the given key
+	CeilingEntry(ctx context.Context, key string) (*_map.Entry, error)
+
+	// LowerEntry returns the entry with the highest key strictly less than the given key
+	LowerEntry(ctx context.Context, key string) (*_map.Entry, error)
+
+	// HigherEntry returns the entry with the lowest key strictly greater than the given key
+	HigherEntry(ctx context.Context, key string) (*_map.Entry, error)
+
+	// FloorKey returns the highest key less than or equal to the given key
+	FloorKey(ctx context.Context, key string) (string, error)
+
+	// CeilingKey returns the lowest key greater than or equal to the given key
+	CeilingKey(ctx context.Context, key string) (string, error)
+
+	// LowerKey returns the highest key strictly less than the given key
+	LowerKey(ctx context.Context, key string) (string, error)
+
+	// HigherKey returns the lowest key strictly greater than the given key
+	HigherKey(ctx context.Context, key string) (string, error)
+
+	// SubMap returns an iterator over the entries with keys in the range [start, end)
+	// An empty start or end leaves the range unbounded on that side. Options can be provided to change the
+	// inclusiveness of the bounds, to iterate in descending order, and to limit the number of entries.
+	// The entries are read from a single snapshot of the map.
+	SubMap(ctx context.Context, start, end string, opts ...SubMapOption) (_map.EntryIterator, error)
+}
+
+// New creates a new tree map
+// The tree map is stored in the Map named by MapName, which must be served by the given connection.
+func New(ctx context.Context, name string, conn *grpc.ClientConn, opts ...primitive.Option) (TreeMap, error) {
+	m, err := _map.New(ctx, MapName(name), conn, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &treeMap{
+		Map: m,
+	}, nil
+}
+
+// treeMap is the implementation of TreeMap on a Map primitive
+type treeMap struct {
+	_map.Map
+}
+
+// ordered returns the given entries options with an unbounded limit, forcing the map to list entries in key order
+// A limit provided in the given options takes precedence.
+func ordered(opts []_map.EntriesOption) []_map.EntriesOption {
+	return append([]_map.EntriesOption{_map.WithLimit(math.MaxInt32)}, opts...)
+}
+
+func (m *treeMap) Entries(ctx context.Context, ch chan<- _map.Entry, opts ..._map.EntriesOption) error {
+	return m.Map.Entries(ctx, ch, ordered(opts)...)
+}
+
+func (m *treeMap) Iterate(ctx context.Context, opts ..._map.EntriesOption) (_map.EntryIterator, error) {
+	return m.Map.Iterate(ctx, ordered(opts)...)
+}
+
+func (m *treeMap) Keys(ctx context.Context, ch chan<- string, opts ..._map.EntriesOption) error {
+	return m.Map.Keys(ctx, ch, ordered(opts)...)
+}
+
+func (m *treeMap) FirstEntry(ctx context.Context) (*_map.Entry, error) {
+	return m.navigate(ctx, "", "", newSubMapOptions(WithLimit(1)))
+}
+
+func (m *treeMap) LastEntry(ctx context.Context) (*_map.Entry, error) {
+	return m.navigate(ctx, "", "", newSubMapOptions(WithDescending(), WithLimit(1)))
+}
+
+func (m *treeMap) FloorEntry(ctx context.Context, key string) (*_map.Entry, error) {
+	if key == "" {
+		return m.Get(ctx, key)
+	}
+	return m.navigate(ctx, "", key, newSubMapOptions(WithInclusiveEnd(), WithDescending(), WithLimit(1)))
+}
+
+func (m *treeMap) CeilingEntry(ctx context.Context, key string) (*_map.Entry, error) {
+	return m.navigate(ctx, key, "", newSubMapOptions(WithLimit(1)))
+}
+
+func (m *treeMap) LowerEntry(ctx context.Context, key string) (*_map.Entry, error) {
+	if key == "" {
+		return nil, errors.NewNotFound("no key lower than the empty key")
+	}
+	return m.navigate(ctx, "", key, newSubMapOptions(WithDescending(), WithLimit(1)))
+}
+
+func (m *treeMap) HigherEntry(ctx context.Context, key string) (*_map.Entry, error) {
+	return m.navigate(ctx, key, "", newSubMapOptions(WithExclusiveStart(), WithLimit(1)))
+}
+
+func (m *treeMap) FloorKey(ctx context.Context, key string) (string, error) {
+	return entryKey(m.FloorEntry(ctx, key))
+}
+
+func (m *treeMap) CeilingKey(ctx context.Context, key string) (string, error) {
+	return entryKey(m.CeilingEntry(ctx, key))
+}
+
+func (m *treeMap) LowerKey(ctx context.Context, key string) (string, error) {
+	return entryKey(m.LowerEntry(ctx, key))
+}
+
+func (m *treeMap) HigherKey(ctx context.Context, key string) (string, error) {
+	return entryKey(m.HigherEntry(ctx, key))
+}
+
+// entryKey returns the key of the given entry
+func entryKey(entry *_map.Entry, err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+	return entry.Key, nil
+}
+
+// navigate returns the first entry of the given sub map
+func (m *treeMap) navigate(ctx context.Context, start, end string, options subMapOptions) (*_map.Entry, error) {
+	entries, err := m.subMap(ctx, start, end, options)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, errors.NewNotFound("no matching entry found")
+	}
+	return &entries[0], nil
+}
+
+func (m *treeMap) SubMap(ctx context.Context, start, end string, opts ...SubMapOption) (_map.EntryIterator, error) {
+	entries, err := m.subMap(ctx, start, end, newSubMapOptions(opts...))
+	if err != nil {
+		return nil, err
+	}
+	return &sliceIterator{entries: entries}, nil
+}
+
+// subMap reads the entries of the given sub map in order
+// The map does not support ordered queries, so the entries in the range are read and sorted by the client.
+func (m *treeMap) subMap(ctx context.Context, start, end string, options subMapOptions) ([]_map.Entry, error) {
+	// The map's range is [start, end), so bounds are adjusted to the next key to change their inclusiveness
+	if options.exclusiveStart {
+		start += "\x00"
+	}
+	if options.inclusiveEnd && end != "" {
+		end += "\x00"
+	}
+	if end != "" && start >= end {
+		return []_map.Entry{}, nil
+	}
+
+	iterator, err := m.Map.Iterate(ctx, _map.WithRange(start, end))
+	if err != nil {
+		return nil, err
+	}
+	defer iterator.Close()
+
+	entries := make([]_map.Entry, 0)
+	for {
+		entry, err := iterator.Next(ctx)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if options.descending {
+			return entries[i].Key > entries[j].Key
+		}
+		return entries[i].Key < entries[j].Key
+	})
+	if options.limit > 0 && len(entries) > options.limit {
+		entries = entries[:options.limit]
+	}
+	return entries, nil
+}
+
+// sliceIterator is an EntryIterator over a slice of entries
+type sliceIterator struct {
+	entries []_map.Entry
+	err     error
+}
+
+func (i *sliceIterator) Next(ctx context.Context) (_map.Entry, error) {
+	if i.err != nil {
+		return _map.Entry{}, i.err
+	}
+	if err := ctx.Err(); err != nil {
+		i.err = errors.From(err)
+		return _map.Entry{}, i.err
+	}
+	if len(i.entries) == 0 {
+		return _map.Entry{}, io.EOF
+	}
+	entry := i.entries[0]
+	i.entries = i.entries[1:]
+	return entry, nil
+}
+
+func (i *sliceIterator) Close() {
+	if i.err == nil {
+		i.err = errors.NewCanceled("iterator is closed")
+	}
+	i.entries = nil
+}
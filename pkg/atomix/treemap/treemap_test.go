@@ -0,0 +1,195 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package treemap
+
+import (
+	"context"
+	primitiveapi "github.com/atomix/atomix-api/go/atomix/primitive"
+	_map "github.com/atomix/atomix-go-client/pkg/atomix/map"
+	"github.com/atomix/atomix-go-client/pkg/atomix/util/test"
+	"github.com/atomix/atomix-go-framework/pkg/atomix/errors"
+	"github.com/atomix/atomix-go-framework/pkg/atomix/logging"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"testing"
+)
+
+func TestTreeMapNavigation(t *testing.T) {
+	logging.SetLevel(logging.DebugLevel)
+
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      MapName("TestTreeMapNavigation"),
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	m, err := New(context.TODO(), "TestTreeMapNavigation", conn)
+	assert.NoError(t, err)
+
+	_, err = m.FirstEntry(context.TODO())
+	assert.True(t, errors.IsNotFound(err))
+	_, err = m.LastEntry(context.TODO())
+	assert.True(t, errors.IsNotFound(err))
+
+	for _, key := range []string{"d", "b", "f", "a", "e"} {
+		_, err = m.Put(context.TODO(), key, []byte(key))
+		assert.NoError(t, err)
+	}
+
+	entry, err := m.FirstEntry(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, "a", entry.Key)
+	assert.Equal(t, "a", string(entry.Value))
+
+	entry, err = m.LastEntry(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, "f", entry.Key)
+
+	entry, err = m.FloorEntry(context.TODO(), "c")
+	assert.NoError(t, err)
+	assert.Equal(t, "b", entry.Key)
+	entry, err = m.FloorEntry(context.TODO(), "d")
+	assert.NoError(t, err)
+	assert.Equal(t, "d", entry.Key)
+	_, err = m.FloorEntry(context.TODO(), "")
+	assert.True(t, errors.IsNotFound(err))
+
+	entry, err = m.CeilingEntry(context.TODO(), "c")
+	assert.NoError(t, err)
+	assert.Equal(t, "d", entry.Key)
+	entry, err = m.CeilingEntry(context.TODO(), "d")
+	assert.NoError(t, err)
+	assert.Equal(t, "d", entry.Key)
+	_, err = m.CeilingEntry(context.TODO(), "g")
+	assert.True(t, errors.IsNotFound(err))
+
+	key, err := m.LowerKey(context.TODO(), "d")
+	assert.NoError(t, err)
+	assert.Equal(t, "b", key)
+	_, err = m.LowerKey(context.TODO(), "a")
+	assert.True(t, errors.IsNotFound(err))
+
+	key, err = m.HigherKey(context.TODO(), "d")
+	assert.NoError(t, err)
+	assert.Equal(t, "e", key)
+	_, err = m.HigherKey(context.TODO(), "f")
+	assert.True(t, errors.IsNotFound(err))
+
+	ch := make(chan string)
+	err = m.Keys(context.TODO(), ch)
+	assert.NoError(t, err)
+	keys := make([]string, 0)
+	for key := range ch {
+		keys = append(keys, key)
+	}
+	assert.Equal(t, []string{"a", "b", "d", "e", "f"}, keys)
+
+	assert.NoError(t, test.Stop())
+}
+
+func TestTreeMapSubMap(t *testing.T) {
+	logging.SetLevel(logging.DebugLevel)
+
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      MapName("TestTreeMapSubMap"),
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	m, err := New(context.TODO(), "TestTreeMapSubMap", conn)
+	assert.NoError(t, err)
+
+	for _, key := range []string{"d", "b", "f", "a", "e", "c"} {
+		_, err = m.Put(context.TODO(), key, []byte(key))
+		assert.NoError(t, err)
+	}
+
+	subMapKeys := func(start, end string, opts ...SubMapOption) []string {
+		iterator, err := m.SubMap(context.TODO(), start, end, opts...)
+		assert.NoError(t, err)
+		defer iterator.Close()
+		keys := make([]string, 0)
+		for {
+			entry, err := iterator.Next(context.TODO())
+			if err == io.EOF {
+				return keys
+			}
+			assert.NoError(t, err)
+			keys = append(keys, entry.Key)
+		}
+	}
+
+	assert.Equal(t, []string{"b", "c", "d"}, subMapKeys("b", "e"))
+	assert.Equal(t, []string{"c", "d", "e"}, subMapKeys("b", "e", WithExclusiveStart(), WithInclusiveEnd()))
+	assert.Equal(t, []string{"d", "c", "b"}, subMapKeys("b", "e", WithDescending()))
+	assert.Equal(t, []string{"a", "b"}, subMapKeys("", "", WithLimit(2)))
+	assert.Equal(t, []string{"f", "e"}, subMapKeys("", "", WithDescending(), WithLimit(2)))
+	assert.Equal(t, []string{"e", "f"}, subMapKeys("e", ""))
+	assert.Equal(t, []string{}, subMapKeys("e", "b"))
+
+	iterator, err := m.Iterate(context.TODO(), _map.WithPrefix(""))
+	assert.NoError(t, err)
+	entry, err := iterator.Next(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, "a", entry.Key)
+	iterator.Close()
+
+	assert.NoError(t, test.Stop())
+}
+
+func TestTreeMapName(t *testing.T) {
+	logging.SetLevel(logging.DebugLevel)
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	// A tree map does not share its entries with the Map of the same name
+	treeConn, err := test.CreateProxy(primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      MapName("TestTreeMapName"),
+	})
+	assert.NoError(t, err)
+	mapConn, err := test.CreateProxy(primitiveapi.PrimitiveId{
+		Type:      _map.Type.String(),
+		Namespace: "test",
+		Name:      "TestTreeMapName",
+	})
+	assert.NoError(t, err)
+
+	treeMap, err := New(context.TODO(), "TestTreeMapName", treeConn)
+	assert.NoError(t, err)
+	m, err := _map.New(context.TODO(), "TestTreeMapName", mapConn)
+	assert.NoError(t, err)
+
+	_, err = m.Put(context.TODO(), "foo", []byte("bar"))
+	assert.NoError(t, err)
+	_, err = treeMap.Get(context.TODO(), "foo")
+	assert.True(t, errors.IsNotFound(err))
+
+	assert.NoError(t, test.Stop())
+}
@@ -48,6 +48,9 @@ import (
 	rsmvalueprotocol "github.com/atomix/atomix-go-framework/pkg/atomix/storage/protocol/rsm/value"
 	"github.com/atomix/atomix-go-local/pkg/atomix/local"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"sync"
 )
 
 // NewRSMTest creates a new RSM-based test
@@ -78,6 +81,8 @@ type RSMTest struct {
 	config   protocolapi.ProtocolConfig
 	protocol *rsmprotocol.Node
 	drivers  []*driver.Driver
+	streams  []*breakableStream
+	mu       sync.Mutex
 }
 
 // Start starts the test cluster
@@ -162,7 +167,7 @@ func (t *RSMTest) CreateProxy(primitiveID primitiveapi.PrimitiveId) (*grpc.Clien
 		return nil, err
 	}
 
-	agentConn, err := grpc.Dial(fmt.Sprintf(":%d", agentPort), grpc.WithInsecure(), grpc.WithContextDialer(t.network.Connect))
+	agentConn, err := grpc.Dial(fmt.Sprintf(":%d", agentPort), grpc.WithInsecure(), grpc.WithContextDialer(t.network.Connect), grpc.WithStreamInterceptor(t.interceptStream))
 	if err != nil {
 		return nil, err
 	}
@@ -179,6 +184,62 @@ func (t *RSMTest) CreateProxy(primitiveID primitiveapi.PrimitiveId) (*grpc.Clien
 	return agentConn, nil
 }
 
+// BreakStreams fails all the streams open on the proxy connections with an Unavailable error
+// Streams opened after the call are not affected.
+func (t *RSMTest) BreakStreams() {
+	t.mu.Lock()
+	streams := t.streams
+	t.streams = nil
+	t.mu.Unlock()
+	for _, stream := range streams {
+		stream.breakStream()
+	}
+}
+
+// interceptStream tracks the streams opened on the proxy connections so they can be broken
+func (t *RSMTest) interceptStream(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	stream, err := streamer(ctx, desc, cc, method, opts...)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	breakable := &breakableStream{
+		ClientStream: stream,
+		cancel:       cancel,
+		broken:       make(chan struct{}),
+	}
+	t.mu.Lock()
+	t.streams = append(t.streams, breakable)
+	t.mu.Unlock()
+	return breakable, nil
+}
+
+// breakableStream is a client stream that can be failed by the test
+type breakableStream struct {
+	grpc.ClientStream
+	cancel context.CancelFunc
+	broken chan struct{}
+	once   sync.Once
+}
+
+func (s *breakableStream) breakStream() {
+	s.once.Do(func() {
+		close(s.broken)
+		s.cancel()
+	})
+}
+
+func (s *breakableStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	select {
+	case <-s.broken:
+		return status.Error(codes.Unavailable, "stream broken")
+	default:
+		return err
+	}
+}
+
 // Stop stops the RSM test cluster
 func (t *RSMTest) Stop() error {
 	for _, driver := range t.drivers {
@@ -15,8 +15,10 @@
 package value
 
 import (
+	"bytes"
 	api "github.com/atomix/atomix-api/go/atomix/primitive/value"
 	"github.com/atomix/atomix-go-client/pkg/atomix/primitive"
+	"github.com/atomix/atomix-go-framework/pkg/atomix/errors"
 	"github.com/atomix/atomix-go-framework/pkg/atomix/meta"
 )
 
@@ -56,3 +58,97 @@ func (o matchOption) beforeSet(request *api.SetRequest) {
 func (o matchOption) afterSet(response *api.SetResponse) {
 
 }
+
+// IfValue updates the value if the current value equals the given value
+// The server only supports version preconditions, so the value is read and compared by the client and the
+// update is applied with an IfMatch precondition for the version that was read. If the value is changed
+// after it's read, the update fails with a conflict error.
+func IfValue(value []byte) SetOption {
+	return conditionOption{
+		check: func(current []byte, object meta.ObjectMeta) error {
+			if !bytes.Equal(current, value) {
+				return errors.NewConflict("value does not match")
+			}
+			return nil
+		},
+	}
+}
+
+// IfExists updates the value only if it has been set
+// Like IfValue, the value is read by the client and the update is applied with an IfMatch precondition for
+// the version that was read.
+func IfExists() SetOption {
+	return conditionOption{
+		check: func(current []byte, object meta.ObjectMeta) error {
+			if object.Revision == 0 {
+				return errors.NewNotFound("value not set")
+			}
+			return nil
+		},
+	}
+}
+
+// conditionOption is a SetOption that checks the current value before it's updated
+type conditionOption struct {
+	check func(value []byte, object meta.ObjectMeta) error
+}
+
+func (o conditionOption) beforeSet(request *api.SetRequest) {
+
+}
+
+func (o conditionOption) afterSet(response *api.SetResponse) {
+
+}
+
+// WatchOption is an option for the Watch method
+type WatchOption interface {
+	beforeWatch(request *api.EventsRequest)
+	afterWatch(response *api.EventsResponse)
+}
+
+// WithBuffer returns a watch option that buffers events between the watch stream and the channel
+// See primitive.WatchBuffer.
+func WithBuffer(size int, policy primitive.OverflowPolicy) *BufferOption {
+	return &BufferOption{
+		WatchBuffer: primitive.NewWatchBuffer(size, policy),
+	}
+}
+
+// BufferOption is a watch option that configures the buffering of watch events
+type BufferOption struct {
+	*primitive.WatchBuffer
+}
+
+func (o *BufferOption) beforeWatch(request *api.EventsRequest) {
+}
+
+func (o *BufferOption) afterWatch(response *api.EventsResponse) {
+}
+
+// WithAutoReconnect returns a watch option that re-establishes the watch stream when it fails
+// The stream is re-opened with backoff until the watch context is done. Because the watch cannot be resumed
+// from the last revision seen, the client tracks the revision of the value and, once the stream is re-opened,
+// reads the value and sends an update event if it changed while the stream was down.
+// An EventResync event is sent once the stream is re-opened, before the events for the missed changes.
+func WithAutoReconnect() WatchOption {
+	return reconnectOption{}
+}
+
+type reconnectOption struct{}
+
+func (o reconnectOption) beforeWatch(request *api.EventsRequest) {
+}
+
+func (o reconnectOption) afterWatch(response *api.EventsResponse) {
+}
+
+// isAutoReconnect returns a bool indicating whether the given watch options enable auto-reconnect
+func isAutoReconnect(opts []WatchOption) bool {
+	for i := range opts {
+		if _, ok := opts[i].(reconnectOption); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -41,13 +41,19 @@ type Value interface {
 	primitive.Primitive
 
 	// Set sets the current value and returns the version
+	// The value must fit in a single gRPC message. Values are not chunked; use a chunked Map for large values.
 	Set(ctx context.Context, value []byte, opts ...SetOption) (meta.ObjectMeta, error)
 
 	// Get gets the current value and version
 	Get(ctx context.Context) ([]byte, meta.ObjectMeta, error)
 
+	// Swap sets the current value and returns the previous value and version
+	// The value is set only if it has not been modified since it was read, and is otherwise retried with backoff
+	// until the context is done.
+	Swap(ctx context.Context, value []byte) ([]byte, meta.ObjectMeta, error)
+
 	// Watch watches the value for changes
-	Watch(ctx context.Context, ch chan<- Event) error
+	Watch(ctx context.Context, ch chan<- Event, opts ...WatchOption) error
 }
 
 // EventType is the type of a set event
@@ -56,6 +62,11 @@ type EventType string
 const (
 	// EventUpdate indicates the value was updated
 	EventUpdate EventType = "update"
+
+	// EventResync indicates the watch stream was re-established and events may have been missed
+	// A resync event carries no value, and is followed by an update event if the value changed while the
+	// stream was down.
+	EventResync EventType = "resync"
 )
 
 // Event is a value change event
@@ -97,6 +108,14 @@ type value struct {
 }
 
 func (v *value) Set(ctx context.Context, value []byte, opts ...SetOption) (meta.ObjectMeta, error) {
+	opts, err := v.resolveConditions(ctx, opts)
+	if err != nil {
+		return meta.ObjectMeta{}, err
+	}
+	value, err = v.Encrypt(value)
+	if err != nil {
+		return meta.ObjectMeta{}, err
+	}
 	request := &api.SetRequest{
 		Headers: v.GetHeaders(),
 		Value: api.Value{
@@ -116,6 +135,33 @@ func (v *value) Set(ctx context.Context, value []byte, opts ...SetOption) (meta.
 	return meta.FromProto(response.Value.ObjectMeta), nil
 }
 
+// resolveConditions checks the conditions in the given options against the current value
+// The conditions are replaced with an IfMatch option for the version of the value that was checked.
+func (v *value) resolveConditions(ctx context.Context, opts []SetOption) ([]SetOption, error) {
+	var conditions []conditionOption
+	resolved := make([]SetOption, 0, len(opts))
+	for _, opt := range opts {
+		if condition, ok := opt.(conditionOption); ok {
+			conditions = append(conditions, condition)
+		} else {
+			resolved = append(resolved, opt)
+		}
+	}
+	if len(conditions) == 0 {
+		return opts, nil
+	}
+	current, object, err := v.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, condition := range conditions {
+		if err := condition.check(current, object); err != nil {
+			return nil, err
+		}
+	}
+	return append(resolved, IfMatch(object)), nil
+}
+
 func (v *value) Get(ctx context.Context) ([]byte, meta.ObjectMeta, error) {
 	request := &api.GetRequest{
 		Headers: v.GetHeaders(),
@@ -124,27 +170,95 @@ func (v *value) Get(ctx context.Context) ([]byte, meta.ObjectMeta, error) {
 	if err != nil {
 		return nil, meta.ObjectMeta{}, errors.From(err)
 	}
-	return response.Value.Value, meta.FromProto(response.Value.ObjectMeta), nil
+	value, err := v.Decrypt(response.Value.Value)
+	if err != nil {
+		return nil, meta.ObjectMeta{}, err
+	}
+	return value, meta.FromProto(response.Value.ObjectMeta), nil
 }
 
-func (v *value) Watch(ctx context.Context, ch chan<- Event) error {
+func (v *value) Swap(ctx context.Context, value []byte) ([]byte, meta.ObjectMeta, error) {
+	var prev []byte
+	var object meta.ObjectMeta
+	var swapErr error
+	err := primitive.RetryWithBackoff(ctx, func() error {
+		var err error
+		prev, object, err = v.Get(ctx)
+		if err != nil {
+			swapErr = err
+			return nil
+		}
+		_, err = v.Set(ctx, value, IfMatch(object))
+		if errors.IsConflict(err) {
+			log.Debugf("Retrying conflicting swap")
+			return err
+		}
+		swapErr = err
+		return nil
+	})
+	if err != nil {
+		return nil, meta.ObjectMeta{}, err
+	} else if swapErr != nil {
+		return nil, meta.ObjectMeta{}, swapErr
+	}
+	return prev, object, nil
+}
+
+func (v *value) Watch(ctx context.Context, ch chan<- Event, opts ...WatchOption) error {
 	request := &api.EventsRequest{
 		Headers: v.GetHeaders(),
 	}
+	for i := range opts {
+		opts[i].beforeWatch(request)
+	}
+
 	stream, err := v.client.Events(ctx, request)
 	if err != nil {
 		return errors.From(err)
 	}
 
+	// To resynchronize a watch after the stream is re-established, the revision of the value must be tracked
+	var tracker *revisionTracker
+	if isAutoReconnect(opts) {
+		_, object, err := v.Get(ctx)
+		if err != nil {
+			return err
+		}
+		tracker = &revisionTracker{
+			revision: object.Revision,
+		}
+	}
+
+	buffer := primitive.NewEventBuffer(primitive.GetWatchBuffer(opts))
+	go buffer.Forward(ctx, ch)
+
+	send := func(event Event) bool {
+		if event.Type == EventResync {
+			return buffer.Push(ctx, "", event)
+		}
+		// Every event updates the same value, so events are coalesced under a single key
+		return buffer.Push(ctx, "value", event)
+	}
+	push := func(event Event) bool {
+		if tracker != nil && !tracker.update(event) {
+			return true
+		}
+		return send(event)
+	}
+
 	openCh := make(chan struct{})
 	go func() {
-		defer close(ch)
+		defer buffer.Close()
 		open := false
 		defer func() {
 			if !open {
 				close(openCh)
 			}
 		}()
+		cancel := func() {}
+		defer func() {
+			cancel()
+		}()
 		for {
 			response, err := stream.Recv()
 			if err == io.EOF ||
@@ -152,19 +266,39 @@ func (v *value) Watch(ctx context.Context, ch chan<- Event) error {
 				errors.IsTimeout(errors.From(err)) {
 				return
 			} else if err != nil {
-				log.Errorf("Watch failed: %v", err)
-				return
+				if tracker == nil {
+					log.Errorf("Watch failed: %v", err)
+					return
+				}
+				log.Warnf("Watch failed; reconnecting: %v", err)
+				cancel()
+				stream, cancel, err = v.resync(ctx, request, tracker, send)
+				if err != nil {
+					cancel = func() {}
+					return
+				}
 			} else {
 				if !open {
 					close(openCh)
 					open = true
 				}
+				for i := range opts {
+					opts[i].afterWatch(response)
+				}
+				value, err := v.Decrypt(response.Event.Value.Value)
+				if err != nil {
+					log.Errorf("Failed to decode value: %v", err)
+					continue
+				}
+
 				switch response.Event.Type {
 				case api.Event_UPDATE:
-					ch <- Event{
+					if !push(Event{
 						ObjectMeta: meta.FromProto(response.Event.Value.ObjectMeta),
 						Type:       EventUpdate,
-						Value:      response.Event.Value.Value,
+						Value:      value,
+					}) {
+						return
 					}
 				}
 			}
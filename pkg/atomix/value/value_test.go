@@ -17,12 +17,15 @@ package value
 import (
 	"context"
 	primitiveapi "github.com/atomix/atomix-api/go/atomix/primitive"
+	"github.com/atomix/atomix-go-client/pkg/atomix/primitive"
 	"github.com/atomix/atomix-go-client/pkg/atomix/util/test"
 	"github.com/atomix/atomix-go-framework/pkg/atomix/errors"
 	"github.com/atomix/atomix-go-framework/pkg/atomix/logging"
 	"github.com/atomix/atomix-go-framework/pkg/atomix/meta"
 	"github.com/stretchr/testify/assert"
+	"strconv"
 	"testing"
+	"time"
 )
 
 func TestValueOperations(t *testing.T) {
@@ -142,3 +145,166 @@ func TestValueOperations(t *testing.T) {
 
 	assert.NoError(t, test.Stop())
 }
+
+func TestValueWatchBuffer(t *testing.T) {
+	logging.SetLevel(logging.DebugLevel)
+
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestValueWatchBuffer",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	value, err := New(context.TODO(), "TestValueWatchBuffer", conn)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := make(chan Event)
+	buffer := WithBuffer(1, primitive.OverflowCoalesce)
+	err = value.Watch(ctx, ch, buffer)
+	assert.NoError(t, err)
+
+	for i := 1; i <= 5; i++ {
+		_, err = value.Set(context.Background(), []byte(strconv.Itoa(i)))
+		assert.NoError(t, err)
+	}
+
+	assert.Eventually(t, func() bool {
+		return buffer.Dropped() >= 3
+	}, 5*time.Second, 10*time.Millisecond)
+
+	var event Event
+	for string(event.Value) != "5" {
+		event = <-ch
+	}
+
+	cancel()
+	for range ch {
+	}
+
+	assert.NoError(t, test.Stop())
+}
+
+func TestValueConditions(t *testing.T) {
+	logging.SetLevel(logging.DebugLevel)
+
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestValueConditions",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	value, err := New(context.TODO(), "TestValueConditions", conn)
+	assert.NoError(t, err)
+
+	_, err = value.Set(context.Background(), []byte("foo"), IfExists())
+	assert.Error(t, err)
+	assert.True(t, errors.IsNotFound(err))
+
+	_, err = value.Set(context.Background(), []byte("foo"))
+	assert.NoError(t, err)
+
+	_, err = value.Set(context.Background(), []byte("bar"), IfValue([]byte("bar")))
+	assert.Error(t, err)
+	assert.True(t, errors.IsConflict(err))
+
+	_, err = value.Set(context.Background(), []byte("bar"), IfValue([]byte("foo")), IfExists())
+	assert.NoError(t, err)
+
+	bytes, _, err := value.Get(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "bar", string(bytes))
+
+	assert.NoError(t, test.Stop())
+}
+
+func TestValueSwap(t *testing.T) {
+	logging.SetLevel(logging.DebugLevel)
+
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestValueSwap",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	value, err := New(context.TODO(), "TestValueSwap", conn)
+	assert.NoError(t, err)
+
+	prev, object, err := value.Swap(context.Background(), []byte("foo"))
+	assert.NoError(t, err)
+	assert.Len(t, prev, 0)
+	assert.Equal(t, meta.Revision(0), object.Revision)
+
+	prev, object, err = value.Swap(context.Background(), []byte("bar"))
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", string(prev))
+	assert.NotEqual(t, meta.Revision(0), object.Revision)
+
+	bytes, current, err := value.Get(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "bar", string(bytes))
+	assert.True(t, current.Revision > object.Revision)
+
+	assert.NoError(t, test.Stop())
+}
+
+func TestValueWatchResync(t *testing.T) {
+	logging.SetLevel(logging.DebugLevel)
+
+	primitiveID := primitiveapi.PrimitiveId{
+		Type:      Type.String(),
+		Namespace: "test",
+		Name:      "TestValueWatchResync",
+	}
+
+	test := test.NewRSMTest()
+	assert.NoError(t, test.Start())
+
+	conn, err := test.CreateProxy(primitiveID)
+	assert.NoError(t, err)
+
+	value, err := New(context.TODO(), "TestValueWatchResync", conn)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := make(chan Event)
+	err = value.Watch(ctx, ch, WithAutoReconnect())
+	assert.NoError(t, err)
+
+	_, err = value.Set(context.Background(), []byte("foo"))
+	assert.NoError(t, err)
+	event := <-ch
+	assert.Equal(t, EventUpdate, event.Type)
+
+	// A broken stream is re-established and signaled with a resync event
+	test.BreakStreams()
+	event = <-ch
+	assert.Equal(t, EventResync, event.Type)
+
+	_, err = value.Set(context.Background(), []byte("bar"))
+	assert.NoError(t, err)
+	event = <-ch
+	assert.Equal(t, EventUpdate, event.Type)
+	assert.Equal(t, "bar", string(event.Value))
+
+	assert.NoError(t, test.Stop())
+}
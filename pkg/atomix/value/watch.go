@@ -0,0 +1,92 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package value
+
+import (
+	"context"
+	api "github.com/atomix/atomix-api/go/atomix/primitive/value"
+	"github.com/atomix/atomix-go-client/pkg/atomix/primitive"
+	"github.com/atomix/atomix-go-framework/pkg/atomix/meta"
+)
+
+// revisionTracker tracks the revision of the value as observed by a watch
+// Once the watch has been resynchronized, the tracked revision is exactly the state delivered to the
+// consumer, and events already reflected in that state are discarded as duplicates.
+type revisionTracker struct {
+	revision meta.Revision
+	dedupe   bool
+}
+
+// update updates the tracked revision with the given event, returning false if the event is a duplicate
+func (t *revisionTracker) update(event Event) bool {
+	if event.Revision <= t.revision {
+		return !t.dedupe
+	}
+	t.revision = event.Revision
+	return true
+}
+
+// resync re-establishes a failed watch stream
+// The stream is re-opened and the value is read. If the value changed while the stream was down, an update
+// event is sent for the current value. Intermediate updates are not sent.
+// An EventResync event is sent before any of these events.
+// The new stream and a function to cancel it are returned once the watch has been resynchronized.
+func (v *value) resync(ctx context.Context, request *api.EventsRequest, tracker *revisionTracker, send func(Event) bool) (api.ValueService_EventsClient, context.CancelFunc, error) {
+	var stream api.ValueService_EventsClient
+	var cancel context.CancelFunc
+	var value []byte
+	var object meta.ObjectMeta
+	err := primitive.RetryWithBackoff(ctx, func() error {
+		var streamCtx context.Context
+		streamCtx, cancel = context.WithCancel(ctx)
+		var err error
+		stream, err = v.client.Events(streamCtx, request)
+		if err != nil {
+			cancel()
+			log.Warnf("Failed to reconnect watch: %v", err)
+			return err
+		}
+		value, object, err = v.Get(ctx)
+		if err != nil {
+			cancel()
+			log.Warnf("Failed to resynchronize watch: %v", err)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !send(Event{Type: EventResync}) {
+		cancel()
+		return nil, nil, ctx.Err()
+	}
+
+	if object.Revision != tracker.revision {
+		event := Event{
+			ObjectMeta: object,
+			Type:       EventUpdate,
+			Value:      value,
+		}
+		if !send(event) {
+			cancel()
+			return nil, nil, ctx.Err()
+		}
+	}
+	tracker.revision = object.Revision
+	tracker.dedupe = true
+	return stream, cancel, nil
+}